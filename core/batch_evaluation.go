@@ -0,0 +1,44 @@
+/*
+Batch governance evaluation for GSAS.
+
+Evaluates many candidate actions against one registry snapshot using a
+bounded worker pool. Each decision is produced exactly as a sequential
+Evaluate call against that snapshot would produce it.
+*/
+
+package core
+
+import (
+	"context"
+	"runtime"
+)
+
+// WithBatchWorkers sets the number of concurrent workers used by EvaluateBatch.
+// Values below one use GOMAXPROCS.
+func WithBatchWorkers(n int) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.batchWorkers = n
+	}
+}
+
+// EvaluateBatch evaluates every context against one consistent registry snapshot.
+// Decisions are returned in input order.
+func (ge *GovernanceEngine) EvaluateBatch(ctx context.Context, contexts []*DeterministicContext, opts ...EvaluateOption) []*GovernanceDecision {
+	decisions := make([]*GovernanceDecision, len(contexts))
+	if len(contexts) == 0 {
+		return decisions
+	}
+
+	snap := ge.snapshot()
+
+	workers := ge.batchWorkers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	parallelFor(len(contexts), workers, func(i int) {
+		decisions[i] = ge.evaluateSnapshot(ctx, snap, contexts[i], opts...)
+	})
+
+	return decisions
+}
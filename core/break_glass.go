@@ -0,0 +1,231 @@
+/*
+Break-glass overrides for GSAS.
+
+A denial can be overridden in an emergency only by a quorum of authorised
+identities, each signing an override token that names the exact proof being
+overridden and the justification. The override is recorded in an
+OverrideProof, which is a distinct type from GovernanceProof and never turns
+the original decision into a permit.
+*/
+
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OverrideProofKind identifies an override proof in its encoding
+const OverrideProofKind = "break_glass_override"
+
+// overrideDomain separates override token signatures from any other use of the keys
+const overrideDomain = "gsas-break-glass-v1"
+
+// OverrideToken is one identity's signed approval to override a specific denial
+type OverrideToken struct {
+	Signer        string `json:"signer"`
+	ProofHash     string `json:"proof_hash"` // CommitmentHash of the denied decision's proof
+	Justification string `json:"justification"`
+	Signature     []byte `json:"signature"`
+}
+
+// overridePayload returns the bytes an override token signs
+func overridePayload(signer, proofHash, justification string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"domain":        overrideDomain,
+		"signer":        signer,
+		"proof_hash":    proofHash,
+		"justification": justification,
+	})
+	return data
+}
+
+// SignOverride creates signer's override token for the proof with hash proofHash
+func SignOverride(signer string, key ed25519.PrivateKey, proofHash, justification string) OverrideToken {
+	return OverrideToken{
+		Signer:        signer,
+		ProofHash:     proofHash,
+		Justification: justification,
+		Signature:     ed25519.Sign(key, overridePayload(signer, proofHash, justification)),
+	}
+}
+
+// commitment returns the SHA256 hash of the token
+func (t OverrideToken) commitment() string {
+	data, _ := json.Marshal(t)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// OverrideProof records that a denial was overridden, by whom and why
+type OverrideProof struct {
+	Kind string `json:"kind"` // Always OverrideProofKind
+
+	// What was overridden
+	OriginalProofHash      string           `json:"original_proof_hash"`
+	OriginalProof          *GovernanceProof `json:"original_proof"`
+	OriginalOutcome        Outcome          `json:"original_outcome"`
+	OriginalFailureReasons []string         `json:"original_failure_reasons"`
+
+	// Who overrode it and why
+	Justification    string   `json:"justification"`
+	Quorum           int      `json:"quorum"`
+	Signers          []string `json:"signers"`           // Sorted
+	TokenCommitments []string `json:"token_commitments"` // SHA256 hashes of the accepted tokens, in signer order
+
+	// Metadata
+	GeneratedAt int64     `json:"generated_at"`
+	ClockKind   ClockKind `json:"clock_kind"`
+}
+
+// CommitmentHash returns the SHA256 hash of the canonical encoding of the override proof
+func (op *OverrideProof) CommitmentHash() string {
+	data, _ := json.Marshal(op)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// QuorumNotMetError is returned when too few valid override tokens are presented
+type QuorumNotMetError struct {
+	Required int
+	Valid    int
+	Rejected []string // Why each rejected token was rejected
+}
+
+func (e *QuorumNotMetError) Error() string {
+	msg := fmt.Sprintf("override quorum not met: %d of %d required approvals", e.Valid, e.Required)
+	if len(e.Rejected) > 0 {
+		msg += fmt.Sprintf(" (rejected: %s)", strings.Join(e.Rejected, "; "))
+	}
+	return msg
+}
+
+// BreakGlassOption configures a BreakGlass authority
+type BreakGlassOption func(*BreakGlass)
+
+// WithOverrideClock sets the clock that stamps override proofs. The default is WallClock.
+func WithOverrideClock(clock Clock) BreakGlassOption {
+	return func(bg *BreakGlass) {
+		if clock != nil {
+			bg.clock = clock
+		}
+	}
+}
+
+// BreakGlass authorises overrides of denials by a quorum of known identities
+type BreakGlass struct {
+	quorum      int
+	authorities map[string]ed25519.PublicKey
+	clock       Clock
+}
+
+// NewBreakGlass creates an authority requiring quorum distinct signatures from authorities
+func NewBreakGlass(quorum int, authorities map[string]ed25519.PublicKey, opts ...BreakGlassOption) (*BreakGlass, error) {
+	if quorum < 1 {
+		return nil, fmt.Errorf("override quorum must be at least 1, got %d", quorum)
+	}
+	if quorum > len(authorities) {
+		return nil, fmt.Errorf("override quorum %d exceeds the %d authorities", quorum, len(authorities))
+	}
+	ids := make([]string, 0, len(authorities))
+	for id := range authorities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// One key under two identities would let a single signer count twice towards the quorum
+	keys := make(map[string]ed25519.PublicKey, len(authorities))
+	owners := make(map[string]string, len(authorities))
+	for _, id := range ids {
+		key := authorities[id]
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("authority '%s' has an invalid public key", id)
+		}
+		if owner, ok := owners[string(key)]; ok {
+			return nil, fmt.Errorf("authorities '%s' and '%s' share a public key", owner, id)
+		}
+		owners[string(key)] = id
+		keys[id] = key
+	}
+
+	bg := &BreakGlass{quorum: quorum, authorities: keys, clock: WallClock{}}
+	for _, opt := range opts {
+		opt(bg)
+	}
+	return bg, nil
+}
+
+// Override overrides a denied decision given a quorum of valid tokens naming its
+// proof and justification. Tokens that are invalid, from unknown signers or
+// duplicated do not count towards the quorum. The decision itself is not modified.
+func (bg *BreakGlass) Override(decision *GovernanceDecision, justification string, tokens ...OverrideToken) (*OverrideProof, error) {
+	if decision == nil || decision.Proof == nil {
+		return nil, errors.New("no governance decision to override")
+	}
+	if decision.Permitted {
+		return nil, errors.New("governance decision already permits execution")
+	}
+	if strings.TrimSpace(justification) == "" {
+		return nil, errors.New("override requires a justification")
+	}
+
+	proofHash := decision.Proof.CommitmentHash()
+	accepted, rejected := bg.validTokens(proofHash, justification, tokens)
+	if len(accepted) < bg.quorum {
+		return nil, &QuorumNotMetError{Required: bg.quorum, Valid: len(accepted), Rejected: rejected}
+	}
+
+	signers := make([]string, 0, len(accepted))
+	for signer := range accepted {
+		signers = append(signers, signer)
+	}
+	sort.Strings(signers)
+	commitments := make([]string, len(signers))
+	for i, signer := range signers {
+		commitments[i] = accepted[signer].commitment()
+	}
+
+	return &OverrideProof{
+		Kind:                   OverrideProofKind,
+		OriginalProofHash:      proofHash,
+		OriginalProof:          decision.Proof.clone(),
+		OriginalOutcome:        decision.Outcome,
+		OriginalFailureReasons: append([]string{}, decision.FailureReasons...),
+		Justification:          justification,
+		Quorum:                 bg.quorum,
+		Signers:                signers,
+		TokenCommitments:       commitments,
+		GeneratedAt:            bg.clock.Now(),
+		ClockKind:              bg.clock.Kind(),
+	}, nil
+}
+
+// validTokens returns the valid tokens for proofHash and justification by
+// signer, and the reasons the remaining tokens were rejected
+func (bg *BreakGlass) validTokens(proofHash, justification string, tokens []OverrideToken) (map[string]OverrideToken, []string) {
+	accepted := make(map[string]OverrideToken)
+	var rejected []string
+	for _, token := range tokens {
+		key, ok := bg.authorities[token.Signer]
+		switch {
+		case !ok:
+			rejected = append(rejected, fmt.Sprintf("'%s' is not an override authority", token.Signer))
+		case token.ProofHash != proofHash:
+			rejected = append(rejected, fmt.Sprintf("token from '%s' names a different proof", token.Signer))
+		case token.Justification != justification:
+			rejected = append(rejected, fmt.Sprintf("token from '%s' names a different justification", token.Signer))
+		case !ed25519.Verify(key, overridePayload(token.Signer, token.ProofHash, token.Justification), token.Signature):
+			rejected = append(rejected, fmt.Sprintf("token from '%s' has an invalid signature", token.Signer))
+		default:
+			if _, dup := accepted[token.Signer]; dup {
+				rejected = append(rejected, fmt.Sprintf("duplicate token from '%s'", token.Signer))
+				continue
+			}
+			accepted[token.Signer] = token
+		}
+	}
+	return accepted, rejected
+}
@@ -0,0 +1,137 @@
+/*
+Clocks for GSAS proof timestamps.
+
+Proofs are stamped by an injected Clock rather than by reading wall-clock
+time directly, so that evaluation can be made fully reproducible.
+*/
+
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockKind identifies the kind of clock that stamped a proof
+type ClockKind string
+
+const (
+	// ClockLogical is a Lamport counter with no relation to wall-clock time
+	ClockLogical ClockKind = "logical"
+	// ClockHybridLogical tracks wall-clock time but never repeats or goes backwards
+	ClockHybridLogical ClockKind = "hybrid_logical"
+	// ClockWall is the system wall clock in Unix nanoseconds
+	ClockWall ClockKind = "wall"
+)
+
+// Clock stamps governance proofs
+type Clock interface {
+	// Now returns the current timestamp
+	Now() int64
+	// Kind identifies the clock
+	Kind() ClockKind
+}
+
+// LogicalClock is a Lamport clock: every reading is one greater than the last
+type LogicalClock struct {
+	mu   sync.Mutex
+	next int64
+}
+
+// NewLogicalClock creates a logical clock whose first reading is start
+func NewLogicalClock(start int64) *LogicalClock {
+	return &LogicalClock{next: start}
+}
+
+// Now returns the next logical timestamp
+func (c *LogicalClock) Now() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.next
+	c.next++
+	return now
+}
+
+// Witness advances the clock past a timestamp observed from another clock
+func (c *LogicalClock) Witness(t int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t >= c.next {
+		c.next = t + 1
+	}
+}
+
+// Kind returns ClockLogical
+func (c *LogicalClock) Kind() ClockKind { return ClockLogical }
+
+// HybridLogicalClock follows a physical clock, but every reading is strictly
+// greater than the last even if the physical clock stalls or steps backwards
+type HybridLogicalClock struct {
+	mu       sync.Mutex
+	physical func() int64
+	last     int64
+}
+
+// NewHybridLogicalClock creates a hybrid logical clock over physical.
+// A nil physical clock uses wall-clock time in Unix nanoseconds.
+func NewHybridLogicalClock(physical func() int64) *HybridLogicalClock {
+	if physical == nil {
+		physical = wallNow
+	}
+	return &HybridLogicalClock{physical: physical}
+}
+
+// Now returns the next hybrid logical timestamp
+func (c *HybridLogicalClock) Now() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pt := c.physical(); pt > c.last {
+		c.last = pt
+	} else {
+		c.last++
+	}
+	return c.last
+}
+
+// Kind returns ClockHybridLogical
+func (c *HybridLogicalClock) Kind() ClockKind { return ClockHybridLogical }
+
+// WallClock reads the system wall clock
+type WallClock struct{}
+
+// Now returns wall-clock time in Unix nanoseconds
+func (WallClock) Now() int64 { return wallNow() }
+
+// Kind returns ClockWall
+func (WallClock) Kind() ClockKind { return ClockWall }
+
+// wallNow returns wall-clock time in Unix nanoseconds
+func wallNow() int64 {
+	return time.Now().UnixNano()
+}
+
+// WithClock sets the clock that stamps the engine's proofs. The default is WallClock.
+func WithClock(clock Clock) EngineOption {
+	return func(ge *GovernanceEngine) {
+		if clock != nil {
+			ge.proofGen = NewProofGenerator(clock)
+		}
+	}
+}
+
+// UsingClock stamps the proof of one call with clock instead of the engine's clock
+func UsingClock(clock Clock) EvaluateOption {
+	return func(c *evaluationConfig) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// AtLogicalTime stamps the proofs of one call with logical time t.
+// Every decision of a batch is stamped with t.
+func AtLogicalTime(t int64) EvaluateOption {
+	return func(c *evaluationConfig) {
+		c.clock = NewLogicalClock(t)
+	}
+}
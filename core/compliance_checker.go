@@ -73,12 +73,14 @@ func (cc *ComplianceChecker) CheckPrimitive(p GovernancePrimitive) (*ComplianceR
 	testCtx := NewDeterministicContext(map[string]interface{}{}, 0)
 	result := p.Evaluate(testCtx)
 
-	if _, ok := result["valid"]; !ok {
+	_, hasValid := result["valid"]
+	_, hasOutcome := result["outcome"]
+	if !hasValid && !hasOutcome {
 		report.Compliant = false
 		report.Violations = append(report.Violations, ComplianceViolation{
 			Primitive:   name,
 			Requirement: "evaluate_contract",
-			Details:     "Evaluate() must return map with 'valid' key",
+			Details:     "Evaluate() must return map with 'valid' or 'outcome' key",
 		})
 	}
 
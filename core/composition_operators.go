@@ -17,8 +17,113 @@ func getPrimitiveName(p GovernancePrimitive, index int) string {
 	return fmt.Sprintf("primitive_%d", index)
 }
 
+// evaluateChild evaluates a composed primitive, converting a panic into a failed
+// result that names the primitive, its version and the panic value.
+// The child's cost is charged to the context's meter first, if there is one.
+// Obligations and advice in the result are normalised and attributed to the child.
+func evaluateChild(p GovernancePrimitive, index int, context interface{}) (map[string]interface{}, Outcome) {
+	name := getPrimitiveName(p, index)
+	if failure := chargeChild(p, context); failure != nil {
+		result := failure.withPrimitive(name, p.Version()).result()
+		return result, ResultOutcome(result)
+	}
+	result, failure := safeEvaluate(p, context)
+	if failure != nil {
+		result = failure.withPrimitive(name, p.Version()).result()
+	}
+
+	obligations, advice, err := resultObligations(result, name)
+	if err == nil {
+		_, err = resultValidity(result)
+	}
+	if err != nil {
+		result = malformedFailure(err).withPrimitive(name, p.Version()).result()
+		return result, ResultOutcome(result)
+	}
+	normalised := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		normalised[k] = v
+	}
+	normalised["obligations"] = obligations
+	normalised["advice"] = advice
+	return normalised, ResultOutcome(normalised)
+}
+
+// conditions accumulates the obligations, advice and validity of composed children
+type conditions struct {
+	obligations []Obligation
+	advice      []Advice
+	validity    *ValidityWindow // Nil while unbounded
+}
+
+// add records the child's advice, its validity window if it allowed the action,
+// and its obligations if it permitted
+func (c *conditions) add(result map[string]interface{}, outcome Outcome) {
+	if advice, ok := result["advice"].([]Advice); ok {
+		c.advice = append(c.advice, advice...)
+	}
+	if !outcome.Allows() {
+		return
+	}
+	if window, err := resultValidity(result); err == nil && window.Bounded() {
+		if c.validity != nil {
+			window = c.validity.Intersect(window)
+		}
+		c.validity = &window
+	}
+	if outcome != Permit {
+		return
+	}
+	if obligations, ok := result["obligations"].([]Obligation); ok {
+		c.obligations = append(c.obligations, obligations...)
+	}
+}
+
+// compositeResult builds a composite primitive result for outcome.
+// Obligations and validity are only attached when the composite allows the action.
+func compositeResult(outcome Outcome, metadata map[string]interface{}, conds conditions) map[string]interface{} {
+	result := map[string]interface{}{
+		"valid":    outcome.Allows(),
+		"outcome":  outcome,
+		"metadata": metadata,
+		"evidence": []interface{}{},
+	}
+	if len(conds.obligations) > 0 && outcome.Allows() {
+		result["obligations"] = conds.obligations
+	}
+	if len(conds.advice) > 0 {
+		result["advice"] = conds.advice
+	}
+	if conds.validity != nil && outcome.Allows() {
+		if conds.validity.From != UnboundedValidity.From {
+			result["valid_from"] = conds.validity.From
+		}
+		if conds.validity.Until != UnboundedValidity.Until {
+			result["valid_until"] = conds.validity.Until
+		}
+	}
+	return result
+}
+
+// panicDetails returns the structured panic details of a failed child result, if any
+func panicDetails(result map[string]interface{}) (map[string]interface{}, bool) {
+	meta, ok := result["metadata"].(map[string]interface{})
+	if !ok || meta["failure"] != string(FailurePanic) {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"primitive_id": meta["primitive_id"],
+		"version":      meta["version"],
+		"panic":        meta["panic"],
+	}, true
+}
+
 // PrimitiveComposer composes primitives with explicit semantics
-type PrimitiveComposer struct{}
+type PrimitiveComposer struct {
+	// Parallelism is the number of goroutines a ParallelAnd may evaluate its
+	// children on. Values below two evaluate them serially.
+	Parallelism int
+}
 
 // SequentialAnd returns a primitive that requires all input primitives to pass in order
 func (pc *PrimitiveComposer) SequentialAnd(primitives []GovernancePrimitive) GovernancePrimitive {
@@ -45,41 +150,49 @@ func (p *sequentialAndPrimitive) Version() string {
 	return fmt.Sprintf("sequential-and-%d", h.Sum64()%1000000)
 }
 
+// Cost is the sum of the children's costs
+func (p *sequentialAndPrimitive) Cost() uint64 { return sumCosts(p.primitives) }
+
+func (p *sequentialAndPrimitive) children() []GovernancePrimitive { return p.primitives }
+
 func (p *sequentialAndPrimitive) Evaluate(context interface{}) map[string]interface{} {
+	outcomes := make([]Outcome, 0, len(p.primitives))
+	var conds conditions
 	for i, primitive := range p.primitives {
-		result := primitive.Evaluate(context)
-		valid, ok := result["valid"].(bool)
-		if !ok || !valid {
-			return map[string]interface{}{
-				"valid": false,
-				"metadata": map[string]interface{}{
-					"reason":       fmt.Sprintf("Primitive %s failed", getPrimitiveName(primitive, i)),
-					"failed_index": i,
-				},
-				"evidence": []interface{}{},
+		result, outcome := evaluateChild(primitive, i, context)
+		conds.add(result, outcome)
+		if !outcome.Allows() {
+			metadata := map[string]interface{}{
+				"reason":       fmt.Sprintf("Primitive %s failed", getPrimitiveName(primitive, i)),
+				"failed_index": i,
 			}
+			if details, ok := panicDetails(result); ok {
+				metadata["failure"] = string(FailurePanic)
+				metadata["panic"] = details
+			}
+			return compositeResult(outcome, metadata, conds)
 		}
+		outcomes = append(outcomes, outcome)
 	}
-	return map[string]interface{}{
-		"valid": true,
-		"metadata": map[string]interface{}{
-			"message": "All primitives passed sequentially",
-		},
-		"evidence": []interface{}{},
-	}
+	return compositeResult(CombineOutcomes(outcomes...), map[string]interface{}{
+		"message": "All primitives passed sequentially",
+	}, conds)
 }
 
-// ParallelAnd returns a primitive that requires all input primitives to pass, order independent
+// ParallelAnd returns a primitive that requires all input primitives to pass, order independent.
+// The input primitives are evaluated on up to pc.Parallelism goroutines.
 func (pc *PrimitiveComposer) ParallelAnd(primitives []GovernancePrimitive) GovernancePrimitive {
 	primitivesCaptured := primitives
 
 	return &parallelAndPrimitive{
 		primitives: primitivesCaptured,
+		workers:    pc.Parallelism,
 	}
 }
 
 type parallelAndPrimitive struct {
 	primitives []GovernancePrimitive
+	workers    int
 }
 
 func (p *parallelAndPrimitive) Version() string {
@@ -94,45 +207,58 @@ func (p *parallelAndPrimitive) Version() string {
 	return fmt.Sprintf("parallel-and-%d", h.Sum64()%1000000)
 }
 
+// Cost is the sum of the children's costs
+func (p *parallelAndPrimitive) Cost() uint64 { return sumCosts(p.primitives) }
+
+func (p *parallelAndPrimitive) children() []GovernancePrimitive { return p.primitives }
+
 func (p *parallelAndPrimitive) Evaluate(context interface{}) map[string]interface{} {
-	results := make([]bool, len(p.primitives))
-	for i, primitive := range p.primitives {
-		result := primitive.Evaluate(context)
-		valid, ok := result["valid"].(bool)
-		results[i] = ok && valid
+	// The children's worst-case cost is reserved up front, so running out of
+	// budget does not depend on the order in which children are scheduled
+	if meter := contextMeter(context); meter != nil {
+		if !meter.charge(p.Cost()) {
+			return meter.failure().result()
+		}
+		context = context.(*DeterministicContext).withMeter(nil)
 	}
 
-	allPassed := true
-	for _, r := range results {
-		if !r {
-			allPassed = false
-			break
+	// Children may be evaluated concurrently and are assembled in input order,
+	// so the result does not depend on scheduling
+	results := make([]map[string]interface{}, len(p.primitives))
+	outcomes := make([]Outcome, len(p.primitives))
+	parallelFor(len(p.primitives), p.workers, func(i int) {
+		results[i], outcomes[i] = evaluateChild(p.primitives[i], i, context)
+	})
+
+	panics := make([]interface{}, 0)
+	var conds conditions
+	for i, result := range results {
+		conds.add(result, outcomes[i])
+		if details, ok := panicDetails(result); ok {
+			panics = append(panics, details)
 		}
 	}
 
-	if allPassed {
-		return map[string]interface{}{
-			"valid": true,
-			"metadata": map[string]interface{}{
-				"message": "All primitives passed in parallel",
-			},
-			"evidence": []interface{}{},
-		}
-	} else {
-		failedPrimitives := make([]string, 0)
-		for i, primitive := range p.primitives {
-			if !results[i] {
-				failedPrimitives = append(failedPrimitives, getPrimitiveName(primitive, i))
-			}
-		}
-		return map[string]interface{}{
-			"valid": false,
-			"metadata": map[string]interface{}{
-				"reason": fmt.Sprintf("Failed primitives: %v", failedPrimitives),
-			},
-			"evidence": []interface{}{},
+	combined := CombineOutcomes(outcomes...)
+	if combined.Allows() {
+		return compositeResult(combined, map[string]interface{}{
+			"message": "All primitives passed in parallel",
+		}, conds)
+	}
+
+	failedPrimitives := make([]string, 0)
+	for i, primitive := range p.primitives {
+		if !outcomes[i].Allows() {
+			failedPrimitives = append(failedPrimitives, getPrimitiveName(primitive, i))
 		}
 	}
+	metadata := map[string]interface{}{
+		"reason": fmt.Sprintf("Failed primitives: %v", failedPrimitives),
+	}
+	if len(panics) > 0 {
+		metadata["panics"] = panics
+	}
+	return compositeResult(combined, metadata, conds)
 }
 
 // Threshold returns a primitive that requires at least k of the input primitives to pass
@@ -163,36 +289,64 @@ func (p *thresholdPrimitive) Version() string {
 	return fmt.Sprintf("threshold-%d-%d", p.k, h.Sum64()%1000000)
 }
 
+// Cost is the sum of the children's costs
+func (p *thresholdPrimitive) Cost() uint64 { return sumCosts(p.primitives) }
+
+func (p *thresholdPrimitive) children() []GovernancePrimitive { return p.primitives }
+
+// Evaluate counts Permit outcomes towards k. NotApplicable children neither
+// count nor block; if every child is NotApplicable so is the threshold.
+// When k is not met, any Indeterminate child makes the result Indeterminate.
 func (p *thresholdPrimitive) Evaluate(context interface{}) map[string]interface{} {
-	results := make([]bool, len(p.primitives))
+	outcomes := make([]Outcome, len(p.primitives))
+	panics := make([]interface{}, 0)
+	var conds conditions
 	for i, primitive := range p.primitives {
-		result := primitive.Evaluate(context)
-		valid, ok := result["valid"].(bool)
-		results[i] = ok && valid
+		result, outcome := evaluateChild(primitive, i, context)
+		outcomes[i] = outcome
+		conds.add(result, outcome)
+		if details, ok := panicDetails(result); ok {
+			panics = append(panics, details)
+		}
 	}
 
-	passedCount := 0
-	for _, r := range results {
-		if r {
+	passedCount, applicable, indeterminate := 0, 0, false
+	for _, o := range outcomes {
+		switch o {
+		case Permit:
 			passedCount++
+		case Indeterminate:
+			indeterminate = true
+		}
+		if o != NotApplicable {
+			applicable++
 		}
 	}
 
-	if passedCount >= p.k {
-		return map[string]interface{}{
-			"valid": true,
-			"metadata": map[string]interface{}{
-				"message": fmt.Sprintf("%d of %d primitives passed", passedCount, len(p.primitives)),
-			},
-			"evidence": []interface{}{},
+	var outcome Outcome
+	var metadata map[string]interface{}
+	switch {
+	case applicable == 0 && len(p.primitives) > 0:
+		outcome = NotApplicable
+		metadata = map[string]interface{}{
+			"message": "No primitives applicable",
+		}
+	case passedCount >= p.k:
+		outcome = Permit
+		metadata = map[string]interface{}{
+			"message": fmt.Sprintf("%d of %d primitives passed", passedCount, len(p.primitives)),
 		}
-	} else {
-		return map[string]interface{}{
-			"valid": false,
-			"metadata": map[string]interface{}{
-				"reason": fmt.Sprintf("Only %d of %d primitives passed, need at least %d", passedCount, len(p.primitives), p.k),
-			},
-			"evidence": []interface{}{},
+	default:
+		outcome = Deny
+		if indeterminate {
+			outcome = Indeterminate
 		}
+		metadata = map[string]interface{}{
+			"reason": fmt.Sprintf("Only %d of %d primitives passed, need at least %d", passedCount, len(p.primitives), p.k),
+		}
+	}
+	if len(panics) > 0 {
+		metadata["panics"] = panics
 	}
-}
\ No newline at end of file
+	return compositeResult(outcome, metadata, conds)
+}
@@ -0,0 +1,416 @@
+/*
+Path queries over DeterministicContext.
+
+A path is either dotted, with list indices and wildcards in brackets
+("orders[0].amount", "orders[*].amount", `meta["a.b"]`), or an RFC 6901
+JSON Pointer ("/orders/0/amount"). A wildcard projects the rest of the path
+over every element of a list.
+*/
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// InvalidPathError is returned for a path that cannot be parsed, or that
+// selects several values where one is required
+type InvalidPathError struct {
+	Path   string
+	Reason string
+}
+
+func (e *InvalidPathError) Error() string {
+	return fmt.Sprintf("invalid path '%s': %s", e.Path, e.Reason)
+}
+
+// PathNotFoundError is returned when a path selects nothing.
+// At is the prefix of the path, rendered concretely, that does not exist.
+type PathNotFoundError struct {
+	Path string
+	At   string
+}
+
+func (e *PathNotFoundError) Error() string {
+	if e.At == e.Path {
+		return fmt.Sprintf("path '%s' not found", e.Path)
+	}
+	return fmt.Sprintf("path '%s' not found: '%s' does not exist", e.Path, e.At)
+}
+
+// stepKind distinguishes the steps of a path
+type stepKind int
+
+const (
+	stepKey      stepKind = iota // Map key
+	stepIndex                    // List index
+	stepWildcard                 // Every list element
+	stepToken                    // JSON Pointer token: a map key or a list index
+)
+
+// pathStep is one step of a parsed path
+type pathStep struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// contextPath is a parsed path
+type contextPath struct {
+	expr     string
+	pointer  bool // Parsed from a JSON Pointer, and rendered as one
+	steps    []pathStep
+	wildcard bool
+}
+
+// pathMatch is a value selected by a path, with its concrete path
+type pathMatch struct {
+	path  string
+	value interface{}
+}
+
+// parsePath parses a dotted path or, if it is empty or starts with '/', a JSON Pointer
+func parsePath(expr string) (*contextPath, error) {
+	if expr == "" || strings.HasPrefix(expr, "/") {
+		return parsePointer(expr)
+	}
+	return parseDotted(expr)
+}
+
+// parsePointer parses an RFC 6901 JSON Pointer
+func parsePointer(expr string) (*contextPath, error) {
+	p := &contextPath{expr: expr, pointer: true}
+	if expr == "" {
+		return p, nil
+	}
+	for _, token := range strings.Split(expr[1:], "/") {
+		for i := 0; i < len(token); i++ {
+			if token[i] == '~' && (i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+				return nil, &InvalidPathError{Path: expr, Reason: "'~' must be followed by '0' or '1'"}
+			}
+		}
+		// ~1 must be decoded before ~0, so "~01" becomes "~1" and not "/"
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		p.steps = append(p.steps, pathStep{kind: stepToken, key: token})
+	}
+	return p, nil
+}
+
+// parseDotted parses a dotted path with bracketed indices, wildcards and quoted keys
+func parseDotted(expr string) (*contextPath, error) {
+	p := &contextPath{expr: expr}
+	invalid := func(format string, args ...interface{}) error {
+		return &InvalidPathError{Path: expr, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	rest := expr
+	expectKey := true
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			switch {
+			case strings.HasPrefix(rest, `["`):
+				quoted, err := strconv.QuotedPrefix(rest[1:])
+				if err != nil || !strings.HasPrefix(rest[1+len(quoted):], "]") {
+					return nil, invalid("unterminated quoted key")
+				}
+				key, _ := strconv.Unquote(quoted)
+				p.steps = append(p.steps, pathStep{kind: stepKey, key: key})
+				end = 1 + len(quoted)
+			case end < 0:
+				return nil, invalid("unterminated '['")
+			case rest[1:end] == "*":
+				p.steps = append(p.steps, pathStep{kind: stepWildcard})
+				p.wildcard = true
+			default:
+				index, err := strconv.Atoi(rest[1:end])
+				if err != nil || index < 0 || strings.HasPrefix(rest[1:end], "+") {
+					return nil, invalid("'%s' is not a list index", rest[1:end])
+				}
+				p.steps = append(p.steps, pathStep{kind: stepIndex, index: index})
+			}
+			rest = rest[end+1:]
+			expectKey = false
+			continue
+		}
+
+		if !expectKey {
+			if rest[0] != '.' {
+				return nil, invalid("expected '.' or '[' before '%s'", rest)
+			}
+			rest = rest[1:]
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, invalid("empty key")
+		}
+		p.steps = append(p.steps, pathStep{kind: stepKey, key: rest[:end]})
+		rest = rest[end:]
+		expectKey = false
+	}
+	if expectKey {
+		return nil, invalid("empty key")
+	}
+	return p, nil
+}
+
+// render appends a step to a concrete path in the style of p
+func (p *contextPath) render(path string, step pathStep) string {
+	switch {
+	case p.pointer && step.kind == stepIndex:
+		return path + "/" + strconv.Itoa(step.index)
+	case p.pointer:
+		return path + "/" + strings.ReplaceAll(strings.ReplaceAll(step.key, "~", "~0"), "/", "~1")
+	case step.kind == stepIndex:
+		return path + "[" + strconv.Itoa(step.index) + "]"
+	case strings.ContainsAny(step.key, ".[]\"") || step.key == "":
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(step.key))
+	}
+	return joinPath(path, step.key)
+}
+
+// pointerIndex reads a JSON Pointer token as a list index: digits without a leading zero
+func pointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.Trim(token, "0123456789") != "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(token)
+	return index, err == nil
+}
+
+// resolve returns the values p selects in root, in order
+func (p *contextPath) resolve(root *pmap) ([]pathMatch, error) {
+	if !p.wildcard {
+		// A path without wildcards selects one value, so no step fans out
+		m := pathMatch{value: root}
+		for _, step := range p.steps {
+			var err error
+			if m, err = p.stepOne(m, step); err != nil {
+				return nil, err
+			}
+		}
+		return []pathMatch{m}, nil
+	}
+	matches := []pathMatch{{value: root}}
+	for _, step := range p.steps {
+		next := make([]pathMatch, 0, len(matches))
+		for _, m := range matches {
+			selected, err := p.step(m, step)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, selected...)
+		}
+		matches = next
+	}
+	return matches, nil
+}
+
+// step applies one step to a selected value
+func (p *contextPath) step(m pathMatch, step pathStep) ([]pathMatch, error) {
+	if step.kind != stepWildcard {
+		selected, err := p.stepOne(m, step)
+		if err != nil {
+			return nil, err
+		}
+		return []pathMatch{selected}, nil
+	}
+	list, ok := m.value.(*pvector)
+	if !ok {
+		return nil, &TypeMismatchError{Key: m.path, Expected: KindList, Actual: kindOf(m.value)}
+	}
+	selected := make([]pathMatch, 0, list.Len())
+	list.each(func(i int, value interface{}) {
+		selected = append(selected, pathMatch{path: p.render(m.path, pathStep{kind: stepIndex, index: i}), value: value})
+	})
+	return selected, nil
+}
+
+// stepOne applies a step other than a wildcard to a selected value
+func (p *contextPath) stepOne(m pathMatch, step pathStep) (pathMatch, error) {
+	if step.kind == stepToken {
+		switch m.value.(type) {
+		case *pmap:
+			step.kind = stepKey
+		case *pvector:
+			index, ok := pointerIndex(step.key)
+			if !ok {
+				return pathMatch{}, &PathNotFoundError{Path: p.expr, At: p.render(m.path, step)}
+			}
+			step = pathStep{kind: stepIndex, index: index}
+		default:
+			return pathMatch{}, &TypeMismatchError{Key: m.path, Expected: KindMap, Actual: kindOf(m.value)}
+		}
+	}
+
+	var value interface{}
+	var exists bool
+	if step.kind == stepKey {
+		container, ok := m.value.(*pmap)
+		if !ok {
+			return pathMatch{}, &TypeMismatchError{Key: m.path, Expected: KindMap, Actual: kindOf(m.value)}
+		}
+		value, exists = container.get(step.key)
+	} else {
+		list, ok := m.value.(*pvector)
+		if !ok {
+			return pathMatch{}, &TypeMismatchError{Key: m.path, Expected: KindList, Actual: kindOf(m.value)}
+		}
+		value, exists = list.get(step.index)
+	}
+	if !exists {
+		return pathMatch{}, &PathNotFoundError{Path: p.expr, At: p.render(m.path, step)}
+	}
+	return pathMatch{path: p.render(m.path, step), value: value}, nil
+}
+
+// queryAll returns every value path selects in dc
+func (dc *DeterministicContext) queryAll(path string) (*contextPath, []pathMatch, error) {
+	p, err := parsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	matches, err := p.resolve(dc.data)
+	return p, matches, err
+}
+
+// queryOne returns the single value path selects in dc
+func (dc *DeterministicContext) queryOne(path string) (pathMatch, error) {
+	p, matches, err := dc.queryAll(path)
+	if err != nil {
+		return pathMatch{}, err
+	}
+	if p.wildcard {
+		return pathMatch{}, &InvalidPathError{Path: path, Reason: "a wildcard selects several values"}
+	}
+	return matches[0], nil
+}
+
+// query reads the single value path selects with convert
+func query[T any](dc *DeterministicContext, path string, expected ValueKind, convert func(interface{}) (T, bool)) (T, error) {
+	m, err := dc.queryOne(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return typed(m.path, m.value, expected, convert)
+}
+
+// queryEach reads every value path selects with convert
+func queryEach[T any](dc *DeterministicContext, path string, expected ValueKind, convert func(interface{}) (T, bool)) ([]T, error) {
+	_, matches, err := dc.queryAll(path)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, len(matches))
+	for i, m := range matches {
+		if results[i], err = typed(m.path, m.value, expected, convert); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Query returns a copy of the value at path. A path with a wildcard returns
+// the list of values it projects; nested wildcards are flattened in order.
+// A missing key or index is a *PathNotFoundError, and stepping into a value
+// of the wrong kind is a *TypeMismatchError whose Key is the concrete path.
+func (dc *DeterministicContext) Query(path string) (interface{}, error) {
+	p, matches, err := dc.queryAll(path)
+	if err != nil {
+		return nil, err
+	}
+	if !p.wildcard {
+		return thaw(matches[0].value), nil
+	}
+	values := make([]interface{}, len(matches))
+	for i, m := range matches {
+		values[i] = thaw(m.value)
+	}
+	return values, nil
+}
+
+// QueryKind returns the kind of the value at path
+func (dc *DeterministicContext) QueryKind(path string) (ValueKind, error) {
+	m, err := dc.queryOne(path)
+	if err != nil {
+		return "", err
+	}
+	return kindOf(m.value), nil
+}
+
+// QueryInt returns the integer at path as an int64
+func (dc *DeterministicContext) QueryInt(path string) (int64, error) {
+	return query(dc, path, KindInt, intValue)
+}
+
+// QueryUint returns the integer at path as a uint64
+func (dc *DeterministicContext) QueryUint(path string) (uint64, error) {
+	return query(dc, path, KindUint, uintValue)
+}
+
+// QueryBigInt returns a copy of the integer at path
+func (dc *DeterministicContext) QueryBigInt(path string) (*big.Int, error) {
+	return query(dc, path, KindBigInt, bigIntValue)
+}
+
+// QueryDecimal returns the decimal or integer at path as an exact Decimal
+func (dc *DeterministicContext) QueryDecimal(path string) (Decimal, error) {
+	return query(dc, path, KindDecimal, decimalValue)
+}
+
+// QueryFloat returns the float at path
+func (dc *DeterministicContext) QueryFloat(path string) (float64, error) {
+	return query(dc, path, KindFloat, floatValue)
+}
+
+// QueryString returns the string at path
+func (dc *DeterministicContext) QueryString(path string) (string, error) {
+	return query(dc, path, KindString, stringValue)
+}
+
+// QueryBool returns the bool at path
+func (dc *DeterministicContext) QueryBool(path string) (bool, error) {
+	return query(dc, path, KindBool, boolValue)
+}
+
+// QueryBytes returns a copy of the bytes at path
+func (dc *DeterministicContext) QueryBytes(path string) ([]byte, error) {
+	return query(dc, path, KindBytes, bytesValue)
+}
+
+// QueryMap returns a copy of the map at path
+func (dc *DeterministicContext) QueryMap(path string) (map[string]interface{}, error) {
+	return query(dc, path, KindMap, mapValue)
+}
+
+// QueryList returns a copy of the list at path
+func (dc *DeterministicContext) QueryList(path string) ([]interface{}, error) {
+	return query(dc, path, KindList, listValue)
+}
+
+// QueryInts returns every integer path selects, as int64s
+func (dc *DeterministicContext) QueryInts(path string) ([]int64, error) {
+	return queryEach(dc, path, KindInt, intValue)
+}
+
+// QueryDecimals returns every decimal or integer path selects, as exact Decimals
+func (dc *DeterministicContext) QueryDecimals(path string) ([]Decimal, error) {
+	return queryEach(dc, path, KindDecimal, decimalValue)
+}
+
+// QueryFloats returns every float path selects
+func (dc *DeterministicContext) QueryFloats(path string) ([]float64, error) {
+	return queryEach(dc, path, KindFloat, floatValue)
+}
+
+// QueryStrings returns every string path selects
+func (dc *DeterministicContext) QueryStrings(path string) ([]string, error) {
+	return queryEach(dc, path, KindString, stringValue)
+}
@@ -0,0 +1,362 @@
+/*
+Declared context schemas for GSAS primitives.
+
+A primitive may declare the context it requires as a subset of JSON Schema.
+The engine validates every context against the union of the schemas of the
+primitives that apply to it before evaluating any of them, and fails closed on
+a missing or invalid field instead of letting a primitive fall back to a default.
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// FailureSchemaViolation means the context did not satisfy a primitive's declared schema
+const FailureSchemaViolation FailureKind = "schema_violation"
+
+// Schema is the subset of JSON Schema a primitive may declare for its context.
+// The root schema describes the whole context, an object.
+// Type is one of "object", "array", "string", "integer", "number", "boolean"
+// or "null"; "integer" matches any number without a fractional part.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *Decimal           `json:"minimum,omitempty"`
+	Maximum              *Decimal           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// ParseSchema decodes a JSON Schema document, rejecting keywords outside the supported subset
+func ParseSchema(data []byte) (*Schema, error) {
+	s, _, err := parseSchema(data)
+	return s, err
+}
+
+// parseSchema decodes and compiles a JSON Schema document
+func parseSchema(data []byte) (*Schema, *compiledSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	var s Schema
+	if err := decoder.Decode(&s); err != nil {
+		return nil, nil, err
+	}
+	compiled, err := compileSchema(&s, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return &s, compiled, nil
+}
+
+// SchemaPrimitive is a primitive that declares the context it requires
+type SchemaPrimitive interface {
+	GovernancePrimitive
+	ContextSchema() *Schema
+}
+
+// SchemaViolation describes one context field that does not satisfy a schema
+type SchemaViolation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// compiledSchema is a validated schema ready to check contexts against
+type compiledSchema struct {
+	schema     *Schema
+	properties map[string]*compiledSchema
+	items      *compiledSchema
+	pattern    *regexp.Regexp
+	enum       []interface{} // Frozen
+}
+
+// schemaTypes are the supported values of Schema.Type
+var schemaTypes = map[string]bool{
+	"": true, "object": true, "array": true, "string": true,
+	"integer": true, "number": true, "boolean": true, "null": true,
+}
+
+// compileSchema validates s, found at path within its root schema, and compiles it
+func compileSchema(s *Schema, path string) (*compiledSchema, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("invalid schema at '%s': %s", path, fmt.Sprintf(format, args...))
+	}
+	if s == nil {
+		return nil, invalid("schema is nil")
+	}
+	if !schemaTypes[s.Type] {
+		return nil, invalid("unsupported type %q", s.Type)
+	}
+	for _, bound := range []*int{s.MinItems, s.MaxItems, s.MinLength, s.MaxLength} {
+		if bound != nil && *bound < 0 {
+			return nil, invalid("length bounds must not be negative")
+		}
+	}
+
+	c := &compiledSchema{schema: s}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return nil, invalid("pattern: %v", err)
+		}
+		c.pattern = pattern
+	}
+	for i, value := range s.Enum {
+		frozen, err := (&freezer{limits: defaultContextLimits()}).freeze(value, nil, 1)
+		if err != nil {
+			return nil, invalid("enum[%d]: %v", i, err)
+		}
+		c.enum = append(c.enum, frozen)
+	}
+	if len(s.Properties) > 0 {
+		c.properties = make(map[string]*compiledSchema, len(s.Properties))
+		for name, property := range s.Properties {
+			compiled, err := compileSchema(property, fieldPath(path, name))
+			if err != nil {
+				return nil, err
+			}
+			c.properties[name] = compiled
+		}
+	}
+	if s.Items != nil {
+		items, err := compileSchema(s.Items, path+"[*]")
+		if err != nil {
+			return nil, err
+		}
+		c.items = items
+	}
+	return c, nil
+}
+
+// fieldPath appends a map key to a dotted field path
+func fieldPath(path, key string) string {
+	return (&contextPath{}).render(path, pathStep{kind: stepKey, key: key})
+}
+
+// declaredSchemas compiles the schemas p and, for a composite, its children declare
+func declaredSchemas(p GovernancePrimitive) ([]*compiledSchema, error) {
+	var schemas []*compiledSchema
+	if sp, ok := p.(SchemaPrimitive); ok {
+		if s := sp.ContextSchema(); s != nil {
+			if s.Type != "" && s.Type != "object" {
+				return nil, fmt.Errorf("invalid schema: the context is an object, not %s", s.Type)
+			}
+			// The schema is compiled from its encoding, so later changes to s have no effect
+			data, err := json.Marshal(s)
+			if err != nil {
+				return nil, err
+			}
+			_, compiled, err := parseSchema(data)
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, compiled)
+		}
+	}
+	if cp, ok := p.(compositePrimitive); ok {
+		for _, child := range cp.children() {
+			childSchemas, err := declaredSchemas(child)
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, childSchemas...)
+		}
+	}
+	return schemas, nil
+}
+
+// numberValue reads any frozen number as an exact Decimal
+func numberValue(v interface{}) (Decimal, bool) {
+	if f, ok := v.(float64); ok {
+		d, err := ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+		return d, err == nil
+	}
+	return decimalValue(v)
+}
+
+// matchesType reports whether a frozen value is of the schema type t
+func matchesType(v interface{}, t string) bool {
+	switch t {
+	case "":
+		return true
+	case "object":
+		_, ok := v.(*pmap)
+		return ok
+	case "array":
+		_, ok := v.(*pvector)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := numberValue(v)
+		return ok
+	case "integer":
+		d, ok := numberValue(v)
+		return ok && d.normalized().scale == 0
+	}
+	return false
+}
+
+// equalValues reports whether two frozen values are equal; numbers compare by value
+func equalValues(a, b interface{}) bool {
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		return ok && x.Equal(y)
+	}
+	var bufA, bufB bytes.Buffer
+	return writeCanonical(&bufA, a) == nil && writeCanonical(&bufB, b) == nil && bufA.String() == bufB.String()
+}
+
+// validate appends the violations of the frozen value v, at path, to violations
+func (c *compiledSchema) validate(v interface{}, path string, violations []SchemaViolation) []SchemaViolation {
+	s := c.schema
+	violate := func(format string, args ...interface{}) []SchemaViolation {
+		return append(violations, SchemaViolation{Field: path, Reason: fmt.Sprintf(format, args...)})
+	}
+	if !matchesType(v, s.Type) {
+		return violate("expected %s, got %s", s.Type, kindOf(v))
+	}
+	if len(c.enum) > 0 {
+		allowed := false
+		for _, e := range c.enum {
+			if equalValues(v, e) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = violate("value is not one of the allowed values")
+		}
+	}
+
+	switch x := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(x)
+		if s.MinLength != nil && length < *s.MinLength {
+			violations = violate("length %d is less than %d", length, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			violations = violate("length %d is greater than %d", length, *s.MaxLength)
+		}
+		if c.pattern != nil && !c.pattern.MatchString(x) {
+			violations = violate("does not match pattern %q", s.Pattern)
+		}
+	case *pmap:
+		for _, name := range s.Required {
+			if _, exists := x.get(name); !exists {
+				violations = append(violations, SchemaViolation{Field: fieldPath(path, name), Reason: "missing required field"})
+			}
+		}
+		for _, name := range x.keys() {
+			value, _ := x.get(name)
+			if property, declared := c.properties[name]; declared {
+				violations = property.validate(value, fieldPath(path, name), violations)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				violations = append(violations, SchemaViolation{Field: fieldPath(path, name), Reason: "unexpected field"})
+			}
+		}
+	case *pvector:
+		if s.MinItems != nil && x.Len() < *s.MinItems {
+			violations = violate("has %d items, fewer than %d", x.Len(), *s.MinItems)
+		}
+		if s.MaxItems != nil && x.Len() > *s.MaxItems {
+			violations = violate("has %d items, more than %d", x.Len(), *s.MaxItems)
+		}
+		if c.items != nil {
+			x.each(func(i int, item interface{}) {
+				violations = c.items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			})
+		}
+	default:
+		if n, ok := numberValue(v); ok {
+			if s.Minimum != nil && n.Cmp(*s.Minimum) < 0 {
+				violations = violate("%s is less than the minimum %s", n, s.Minimum)
+			}
+			if s.Maximum != nil && n.Cmp(*s.Maximum) > 0 {
+				violations = violate("%s is greater than the maximum %s", n, s.Maximum)
+			}
+		}
+	}
+	return violations
+}
+
+// schemaFailure validates dctx against schemas, returning a schema violation
+// failure naming every offending field, or nil if dctx satisfies them all
+func schemaFailure(schemas []*compiledSchema, dctx *DeterministicContext) *evaluationFailure {
+	if len(schemas) == 0 {
+		return nil
+	}
+	data := emptyPmap
+	if dctx != nil {
+		data = dctx.data
+	}
+	var violations []SchemaViolation
+	for _, schema := range schemas {
+		violations = schema.validate(data, "", violations)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	// Composite children may declare the same field; report each violation once
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Field != violations[j].Field {
+			return violations[i].Field < violations[j].Field
+		}
+		return violations[i].Reason < violations[j].Reason
+	})
+	violations = slices.Compact(violations)
+	fields := make([]interface{}, len(violations))
+	details := make([]interface{}, len(violations))
+	for i, v := range violations {
+		fields[i] = v.Field
+		details[i] = map[string]interface{}{"field": v.Field, "reason": v.Reason}
+	}
+	return &evaluationFailure{
+		kind:    FailureSchemaViolation,
+		reason:  fmt.Sprintf("context field '%s': %s", violations[0].Field, violations[0].Reason),
+		details: map[string]interface{}{"field": violations[0].Field, "fields": fields, "violations": details},
+	}
+}
+
+// checkSchemas validates dctx against the schemas of every applicable registration
+// before any is evaluated. It returns the failure of each violated registration,
+// and whether any of them gates the decision.
+func checkSchemas(applicable []*registration, dctx *DeterministicContext) (map[*registration]*evaluationFailure, bool) {
+	var violated map[*registration]*evaluationFailure
+	gating := false
+	for _, reg := range applicable {
+		failure := schemaFailure(reg.schemas, dctx)
+		if failure == nil {
+			continue
+		}
+		if violated == nil {
+			violated = make(map[*registration]*evaluationFailure)
+		}
+		violated[reg] = failure.withPrimitive(reg.id, reg.version)
+		gating = gating || !reg.shadow
+	}
+	return violated, gating
+}
@@ -0,0 +1,92 @@
+/*
+Fail-closed context construction for GSAS.
+
+A context is built from its data or not at all: unsupported and non-finite
+values, and data beyond the configured nesting depth, key count or size, are
+rejected with the path of the offending value.
+*/
+
+package core
+
+import "fmt"
+
+// FailureInvalidContext means the evaluation context was built from data that failed validation
+const FailureInvalidContext FailureKind = "invalid_context"
+
+// DefaultMaxContextDepth is the nesting depth allowed when none is configured
+const DefaultMaxContextDepth = 64
+
+// contextLimits bounds the data of a context; zero key and byte limits are unlimited
+type contextLimits struct {
+	maxDepth int
+	maxKeys  int
+	maxBytes int
+}
+
+// defaultContextLimits returns the limits applied when no options are given
+func defaultContextLimits() contextLimits {
+	return contextLimits{maxDepth: DefaultMaxContextDepth}
+}
+
+// ContextOption configures the validation of a context's data
+type ContextOption func(*contextLimits)
+
+// WithMaxDepth limits how deeply values may nest; a top-level scalar has depth 1.
+// Non-positive depths keep the default.
+func WithMaxDepth(depth int) ContextOption {
+	return func(l *contextLimits) {
+		if depth > 0 {
+			l.maxDepth = depth
+		}
+	}
+}
+
+// WithMaxKeys limits the number of map keys at every level of the data
+func WithMaxKeys(keys int) ContextOption {
+	return func(l *contextLimits) {
+		l.maxKeys = keys
+	}
+}
+
+// WithMaxBytes limits the size of the data: the length of every key, string
+// and byte string, eight bytes per fixed-width number, the magnitude bytes of
+// big integers and decimals, and one byte per bool or null
+func WithMaxBytes(size int) ContextOption {
+	return func(l *contextLimits) {
+		l.maxBytes = size
+	}
+}
+
+// ContextValidationError reports the value a context could not be built from.
+// Path is dotted, with list indices in brackets, e.g. "orders[2].amount".
+type ContextValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ContextValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// BuildDeterministicContext creates a deterministic context from data, or
+// returns a *ContextValidationError naming the first value that was rejected
+func BuildDeterministicContext(data map[string]interface{}, logicalTime int, opts ...ContextOption) (*DeterministicContext, error) {
+	f := &freezer{limits: defaultContextLimits()}
+	for _, opt := range opts {
+		opt(&f.limits)
+	}
+	frozen, err := f.freezeMap(data, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &DeterministicContext{data: frozen, time: logicalTime, limits: f.limits, keys: f.keys, size: f.size}, nil
+}
+
+// Err returns the validation error of a context whose data was rejected.
+// The engine denies every evaluation of such a context.
+func (dc *DeterministicContext) Err() error {
+	if dc == nil {
+		return nil
+	}
+	return dc.err
+}
@@ -0,0 +1,352 @@
+/*
+Typed context values for GSAS.
+
+Context values keep the type they were given: signed and unsigned integers,
+big integers, exact decimals, floats, strings, bools, bytes, maps and lists
+are distinct, and the canonical encoding tags every value with its kind.
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// ValueKind names the type of a context value
+type ValueKind string
+
+const (
+	KindNull    ValueKind = "null"
+	KindBool    ValueKind = "bool"
+	KindInt     ValueKind = "int"
+	KindUint    ValueKind = "uint"
+	KindBigInt  ValueKind = "bigint"
+	KindDecimal ValueKind = "decimal"
+	KindFloat   ValueKind = "float"
+	KindString  ValueKind = "string"
+	KindBytes   ValueKind = "bytes"
+	KindMap     ValueKind = "map"
+	KindList    ValueKind = "list"
+)
+
+// frozenBytes is the immutable form of a []byte value
+type frozenBytes string
+
+// MarshalJSON encodes the bytes as base64, as encoding/json does for []byte
+func (b frozenBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]byte(b))
+}
+
+// KeyNotFoundError is returned when a context has no value for a key
+type KeyNotFoundError struct {
+	Key string
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("key '%s' not found", e.Key)
+}
+
+// TypeMismatchError is returned when a context value cannot be read as the requested kind
+type TypeMismatchError struct {
+	Key      string
+	Expected ValueKind
+	Actual   ValueKind
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("key '%s' holds %s, not %s", e.Key, e.Actual, e.Expected)
+}
+
+// kindOf returns the kind of a frozen value
+func kindOf(v interface{}) ValueKind {
+	switch v.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case int64:
+		return KindInt
+	case uint64:
+		return KindUint
+	case *big.Int:
+		return KindBigInt
+	case Decimal:
+		return KindDecimal
+	case float64:
+		return KindFloat
+	case string:
+		return KindString
+	case frozenBytes:
+		return KindBytes
+	case *pmap:
+		return KindMap
+	case *pvector:
+		return KindList
+	}
+	return ValueKind(fmt.Sprintf("%T", v))
+}
+
+// Integers convert between kinds only when no precision is lost; floats never
+// convert, as they are not exact
+
+// intValue reads a frozen integer as an int64
+func intValue(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case *big.Int:
+		return n.Int64(), n.IsInt64()
+	}
+	return 0, false
+}
+
+// uintValue reads a frozen integer as a uint64
+func uintValue(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case *big.Int:
+		return n.Uint64(), n.IsUint64()
+	}
+	return 0, false
+}
+
+// bigIntValue reads a frozen integer as a fresh *big.Int
+func bigIntValue(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return big.NewInt(n), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	case *big.Int:
+		return new(big.Int).Set(n), true
+	}
+	return nil, false
+}
+
+// decimalValue reads a frozen decimal or integer as a Decimal
+func decimalValue(v interface{}) (Decimal, bool) {
+	if d, ok := v.(Decimal); ok {
+		return d, true
+	}
+	if n, ok := bigIntValue(v); ok {
+		return Decimal{unscaled: n}, true
+	}
+	return Decimal{}, false
+}
+
+// lookup returns the frozen value stored under key
+func (dc *DeterministicContext) lookup(key string) (interface{}, error) {
+	if val, exists := dc.data.get(key); exists {
+		return val, nil
+	}
+	return nil, &KeyNotFoundError{Key: key}
+}
+
+// floatValue reads a frozen float
+func floatValue(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// stringValue reads a frozen string
+func stringValue(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	return s, ok
+}
+
+// boolValue reads a frozen bool
+func boolValue(v interface{}) (bool, bool) {
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// bytesValue reads frozen bytes as a fresh []byte
+func bytesValue(v interface{}) ([]byte, bool) {
+	b, ok := v.(frozenBytes)
+	return []byte(b), ok
+}
+
+// mapValue reads a frozen map as a fresh mutable map
+func mapValue(v interface{}) (map[string]interface{}, bool) {
+	m, ok := v.(*pmap)
+	if !ok {
+		return nil, false
+	}
+	return thawMap(m), true
+}
+
+// listValue reads a frozen list as a fresh mutable slice
+func listValue(v interface{}) ([]interface{}, bool) {
+	l, ok := v.(*pvector)
+	if !ok {
+		return nil, false
+	}
+	return thaw(l).([]interface{}), true
+}
+
+// typed reads val, found under key, with convert, reporting a mismatch as expected
+func typed[T any](key string, val interface{}, expected ValueKind, convert func(interface{}) (T, bool)) (T, error) {
+	result, ok := convert(val)
+	if !ok {
+		var zero T
+		return zero, &TypeMismatchError{Key: key, Expected: expected, Actual: kindOf(val)}
+	}
+	return result, nil
+}
+
+// get reads the value under key with convert
+func get[T any](dc *DeterministicContext, key string, expected ValueKind, convert func(interface{}) (T, bool)) (T, error) {
+	val, err := dc.lookup(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return typed(key, val, expected, convert)
+}
+
+// Kind returns the kind of the value stored under key
+func (dc *DeterministicContext) Kind(key string) (ValueKind, error) {
+	val, err := dc.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return kindOf(val), nil
+}
+
+// GetInt returns the integer under key as an int64
+func (dc *DeterministicContext) GetInt(key string) (int64, error) {
+	return get(dc, key, KindInt, intValue)
+}
+
+// GetUint returns the integer under key as a uint64
+func (dc *DeterministicContext) GetUint(key string) (uint64, error) {
+	return get(dc, key, KindUint, uintValue)
+}
+
+// GetBigInt returns a copy of the integer under key
+func (dc *DeterministicContext) GetBigInt(key string) (*big.Int, error) {
+	return get(dc, key, KindBigInt, bigIntValue)
+}
+
+// GetDecimal returns the decimal or integer under key as an exact Decimal
+func (dc *DeterministicContext) GetDecimal(key string) (Decimal, error) {
+	return get(dc, key, KindDecimal, decimalValue)
+}
+
+// GetFloat returns the float under key
+func (dc *DeterministicContext) GetFloat(key string) (float64, error) {
+	return get(dc, key, KindFloat, floatValue)
+}
+
+// GetString returns the string under key
+func (dc *DeterministicContext) GetString(key string) (string, error) {
+	return get(dc, key, KindString, stringValue)
+}
+
+// GetBool returns the bool under key
+func (dc *DeterministicContext) GetBool(key string) (bool, error) {
+	return get(dc, key, KindBool, boolValue)
+}
+
+// GetBytes returns a copy of the bytes under key
+func (dc *DeterministicContext) GetBytes(key string) ([]byte, error) {
+	return get(dc, key, KindBytes, bytesValue)
+}
+
+// GetMap returns a copy of the map under key
+func (dc *DeterministicContext) GetMap(key string) (map[string]interface{}, error) {
+	return get(dc, key, KindMap, mapValue)
+}
+
+// GetList returns a copy of the list under key
+func (dc *DeterministicContext) GetList(key string) ([]interface{}, error) {
+	return get(dc, key, KindList, listValue)
+}
+
+// writeCanonical appends the type-tagged canonical encoding of a frozen value.
+// Scalars are a kind tag and a payload (numbers are terminated by ';', strings
+// are quoted), so values of different kinds never encode alike; map keys are sorted.
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteByte('n')
+	case bool:
+		if x {
+			buf.WriteByte('t')
+		} else {
+			buf.WriteByte('f')
+		}
+	case int64:
+		buf.WriteByte('i')
+		buf.WriteString(strconv.FormatInt(x, 10))
+		buf.WriteByte(';')
+	case uint64:
+		buf.WriteByte('u')
+		buf.WriteString(strconv.FormatUint(x, 10))
+		buf.WriteByte(';')
+	case *big.Int:
+		buf.WriteByte('I')
+		buf.WriteString(x.String())
+		buf.WriteByte(';')
+	case Decimal:
+		// Decimals that differ only in trailing zeros are the same number
+		buf.WriteByte('d')
+		buf.WriteString(x.normalized().String())
+		buf.WriteByte(';')
+	case float64:
+		buf.WriteByte('F')
+		buf.WriteString(strconv.FormatFloat(x, 'g', -1, 64))
+		buf.WriteByte(';')
+	case string:
+		buf.WriteByte('s')
+		buf.WriteString(strconv.Quote(x))
+	case frozenBytes:
+		buf.WriteByte('x')
+		buf.WriteString(hex.EncodeToString([]byte(x)))
+		buf.WriteByte(';')
+	case *pmap:
+		buf.WriteByte('{')
+		for i, key := range x.keys() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Quote(key))
+			buf.WriteByte(':')
+			value, _ := x.get(key)
+			if err := writeCanonical(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case *pvector:
+		buf.WriteByte('[')
+		var err error
+		x.each(func(i int, value interface{}) {
+			if err != nil {
+				return
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err = writeCanonical(buf, value)
+		})
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+	default:
+		return fmt.Errorf("unsupported context value of type %T", v)
+	}
+	return nil
+}
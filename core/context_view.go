@@ -0,0 +1,143 @@
+/*
+Read-only views of DeterministicContext values.
+
+Get and Query return mutable copies, which for a large nested value costs a
+deep copy on every read. A view reads the frozen value in place instead:
+stepping into maps and lists and reading scalars copies nothing, and only
+Value, AsBigInt and AsBytes return copies.
+*/
+
+package core
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ContextView is a read-only view of a context value
+type ContextView struct {
+	pointer bool // Nested paths are rendered as JSON Pointers
+	path    string
+	value   interface{}
+}
+
+// View returns a view of the value stored under key
+func (dc *DeterministicContext) View(key string) (ContextView, error) {
+	val, err := dc.lookup(key)
+	if err != nil {
+		return ContextView{}, err
+	}
+	return ContextView{path: (&contextPath{}).render("", pathStep{kind: stepKey, key: key}), value: val}, nil
+}
+
+// QueryView returns a view of the single value at path
+func (dc *DeterministicContext) QueryView(path string) (ContextView, error) {
+	p, matches, err := dc.queryAll(path)
+	if err != nil {
+		return ContextView{}, err
+	}
+	if p.wildcard {
+		return ContextView{}, &InvalidPathError{Path: path, Reason: "a wildcard selects several values"}
+	}
+	return ContextView{pointer: p.pointer, path: matches[0].path, value: matches[0].value}, nil
+}
+
+// Path returns the concrete path of the viewed value
+func (v ContextView) Path() string {
+	return v.path
+}
+
+// Kind returns the kind of the viewed value
+func (v ContextView) Kind() ValueKind {
+	return kindOf(v.value)
+}
+
+// Len returns the number of entries of a map or list, and zero otherwise
+func (v ContextView) Len() int {
+	switch x := v.value.(type) {
+	case *pmap:
+		return x.Len()
+	case *pvector:
+		return x.Len()
+	}
+	return 0
+}
+
+// Keys returns the keys of a map in sorted order
+func (v ContextView) Keys() ([]string, error) {
+	m, ok := v.value.(*pmap)
+	if !ok {
+		return nil, &TypeMismatchError{Key: v.path, Expected: KindMap, Actual: kindOf(v.value)}
+	}
+	return m.keys(), nil
+}
+
+// Field returns a view of the value under key in a map
+func (v ContextView) Field(key string) (ContextView, error) {
+	return v.step(pathStep{kind: stepKey, key: key})
+}
+
+// Index returns a view of the element at i in a list
+func (v ContextView) Index(i int) (ContextView, error) {
+	return v.step(pathStep{kind: stepIndex, index: i})
+}
+
+// step applies one path step to the viewed value
+func (v ContextView) step(step pathStep) (ContextView, error) {
+	syntax := contextPath{pointer: v.pointer}
+	selected, err := syntax.stepOne(pathMatch{path: v.path, value: v.value}, step)
+	if err != nil {
+		var notFound *PathNotFoundError
+		if errors.As(err, &notFound) {
+			// The missing step is the last of the path
+			notFound.Path = notFound.At
+		}
+		return ContextView{}, err
+	}
+	return ContextView{pointer: v.pointer, path: selected.path, value: selected.value}, nil
+}
+
+// Value returns a mutable copy of the viewed value
+func (v ContextView) Value() interface{} {
+	return thaw(v.value)
+}
+
+// AsInt returns the viewed integer as an int64
+func (v ContextView) AsInt() (int64, error) {
+	return typed(v.path, v.value, KindInt, intValue)
+}
+
+// AsUint returns the viewed integer as a uint64
+func (v ContextView) AsUint() (uint64, error) {
+	return typed(v.path, v.value, KindUint, uintValue)
+}
+
+// AsBigInt returns a copy of the viewed integer
+func (v ContextView) AsBigInt() (*big.Int, error) {
+	return typed(v.path, v.value, KindBigInt, bigIntValue)
+}
+
+// AsDecimal returns the viewed decimal or integer as an exact Decimal
+func (v ContextView) AsDecimal() (Decimal, error) {
+	return typed(v.path, v.value, KindDecimal, decimalValue)
+}
+
+// AsFloat returns the viewed float
+func (v ContextView) AsFloat() (float64, error) {
+	return typed(v.path, v.value, KindFloat, floatValue)
+}
+
+// AsString returns the viewed string
+func (v ContextView) AsString() (string, error) {
+	return typed(v.path, v.value, KindString, stringValue)
+}
+
+// AsBool returns the viewed bool
+func (v ContextView) AsBool() (bool, error) {
+	return typed(v.path, v.value, KindBool, boolValue)
+}
+
+// AsBytes returns a copy of the viewed bytes
+func (v ContextView) AsBytes() ([]byte, error) {
+	return typed(v.path, v.value, KindBytes, bytesValue)
+}
@@ -0,0 +1,151 @@
+/*
+Evaluation cost budgets for GSAS.
+
+Primitives declare a cost, and evaluation meters the cumulative cost of every
+primitive it runs, including the children of composed primitives. A decision
+that exceeds its budget fails closed with a structured budget_exhausted signal.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultPrimitiveCost is the cost of a primitive that does not declare one
+const DefaultPrimitiveCost uint64 = 1
+
+// FailureBudgetExhausted means evaluation exceeded the decision's cost budget
+const FailureBudgetExhausted FailureKind = "budget_exhausted"
+
+// CostedPrimitive is a primitive that declares its evaluation cost
+type CostedPrimitive interface {
+	GovernancePrimitive
+	Cost() uint64
+}
+
+// compositePrimitive is a primitive that meters the cost of its own children
+type compositePrimitive interface {
+	GovernancePrimitive
+	children() []GovernancePrimitive
+}
+
+// primitiveCost returns the declared cost of p. A composite costs the sum of its children.
+func primitiveCost(p GovernancePrimitive) uint64 {
+	if cp, ok := p.(CostedPrimitive); ok {
+		return cp.Cost()
+	}
+	return DefaultPrimitiveCost
+}
+
+// sumCosts returns the total cost of primitives, saturating on overflow
+func sumCosts(primitives []GovernancePrimitive) uint64 {
+	var total uint64
+	for _, p := range primitives {
+		cost := primitiveCost(p)
+		if total+cost < total {
+			return ^uint64(0)
+		}
+		total += cost
+	}
+	return total
+}
+
+// WithCostBudget bounds the cumulative cost of every decision. Zero is unbounded.
+func WithCostBudget(budget uint64) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.costBudget = budget
+	}
+}
+
+// WithBudget overrides the engine's cost budget for one call. Zero is unbounded.
+func WithBudget(budget uint64) EvaluateOption {
+	return func(c *evaluationConfig) {
+		c.budget = budget
+	}
+}
+
+// costMeter tracks the cost consumed by one decision against its budget
+type costMeter struct {
+	mu        sync.Mutex
+	budget    uint64
+	consumed  uint64
+	exhausted bool
+}
+
+// newCostMeter creates a meter for budget, or nil if budget is unbounded
+func newCostMeter(budget uint64) *costMeter {
+	if budget == 0 {
+		return nil
+	}
+	return &costMeter{budget: budget}
+}
+
+// charge consumes cost, reporting false and exhausting the meter if it exceeds the budget
+func (m *costMeter) charge(cost uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.exhausted || cost > m.budget-m.consumed {
+		m.exhausted = true
+		return false
+	}
+	m.consumed += cost
+	return true
+}
+
+// state returns the consumed cost and whether the budget is exhausted
+func (m *costMeter) state() (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.consumed, m.exhausted
+}
+
+// failure describes the exhausted budget
+func (m *costMeter) failure() *evaluationFailure {
+	consumed, _ := m.state()
+	return &evaluationFailure{
+		kind:   FailureBudgetExhausted,
+		reason: fmt.Sprintf("cost budget of %d exhausted", m.budget),
+		details: map[string]interface{}{
+			"budget":   m.budget,
+			"consumed": consumed,
+		},
+	}
+}
+
+// contextMeter returns the cost meter attached to an evaluation context, if any
+func contextMeter(context interface{}) *costMeter {
+	if dc, ok := context.(*DeterministicContext); ok && dc != nil {
+		return dc.meter
+	}
+	return nil
+}
+
+// chargeChild charges the cost of evaluating a composed child. Composites
+// charge their own children, so only leaf primitives are charged here.
+func chargeChild(p GovernancePrimitive, context interface{}) *evaluationFailure {
+	meter := contextMeter(context)
+	if meter == nil {
+		return nil
+	}
+	if _, ok := p.(compositePrimitive); ok {
+		return nil
+	}
+	if !meter.charge(primitiveCost(p)) {
+		return meter.failure()
+	}
+	return nil
+}
+
+// EstimateCost returns an upper bound on the cost of one decision against the
+// current registry. Shadow primitives are not metered and are excluded.
+func (ge *GovernanceEngine) EstimateCost() uint64 {
+	primitives := make([]GovernancePrimitive, 0)
+	for _, reg := range ge.snapshot().order {
+		if !reg.shadow {
+			primitives = append(primitives, reg.primitive)
+		}
+	}
+	return sumCosts(primitives)
+}
@@ -0,0 +1,174 @@
+/*
+Exact decimal numbers for GSAS contexts.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base-10 number, unscaled × 10^-scale.
+// Decimals are immutable; the zero value is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32 // Digits after the decimal point, never negative
+}
+
+// NewDecimal returns the decimal unscaled × 10^-scale
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+// NewDecimalFromBigInt returns the decimal unscaled × 10^-scale, copying unscaled
+func NewDecimalFromBigInt(unscaled *big.Int, scale int32) Decimal {
+	if unscaled == nil {
+		return Decimal{}
+	}
+	return newDecimal(new(big.Int).Set(unscaled), scale)
+}
+
+// newDecimal takes ownership of unscaled, folding a negative scale into it
+func newDecimal(unscaled *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-int64(scale)))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// pow10 returns 10^n
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+// maxDecimalScale bounds the exponent of parsed decimals, so an untrusted literal
+// cannot demand an arbitrarily large power of ten
+const maxDecimalScale = 10000
+
+// ParseDecimal parses a decimal literal such as "-12.50" or "1.5e3"
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		exponent = e
+		mantissa = s[:i]
+	}
+
+	digits, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits+fraction == "" || strings.Trim(digits+fraction, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(sign+digits+fraction, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	scale := int64(len(fraction)) - exponent
+	if scale > maxDecimalScale || scale < -maxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+	}
+	return newDecimal(unscaled, int32(scale)), nil
+}
+
+// Unscaled returns a copy of the decimal's unscaled value
+func (d Decimal) Unscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	if d.unscaled == nil {
+		return 0
+	}
+	return d.unscaled.Sign()
+}
+
+// Cmp compares d and other numerically, returning -1, 0 or +1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := d.Unscaled(), other.Unscaled()
+	switch {
+	case d.scale < other.scale:
+		a.Mul(a, pow10(int64(other.scale-d.scale)))
+	case d.scale > other.scale:
+		b.Mul(b, pow10(int64(d.scale-other.scale)))
+	}
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and other are numerically equal, whatever their scales
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// normalized returns d with trailing fractional zeros removed
+func (d Decimal) normalized() Decimal {
+	unscaled, scale := d.Unscaled(), d.scale
+	ten, rem := big.NewInt(10), new(big.Int)
+	for scale > 0 {
+		q, r := new(big.Int).QuoRem(unscaled, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		unscaled, scale = q, scale-1
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// String formats d with exactly Scale digits after the decimal point
+func (d Decimal) String() string {
+	digits := d.Unscaled().String()
+	if d.scale == 0 {
+		return digits
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON encodes d as an exact JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes d from a JSON number or string
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Decision cache for GSAS.
+
+Caches governance decisions keyed by the canonical hash of the evaluated
+context, its logical time and the registry configuration. Reused decisions
+carry a proof explicitly marked as a cache hit.
+*/
+
+package core
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// WithDecisionCache enables a least-recently-used decision cache bounded by
+// maxEntries decisions and maxBytes of encoded decisions. A bound of zero or
+// less is unlimited, but at least one bound must be set.
+func WithDecisionCache(maxEntries, maxBytes int) EngineOption {
+	return func(ge *GovernanceEngine) {
+		if maxEntries <= 0 && maxBytes <= 0 {
+			return
+		}
+		ge.cache = newDecisionCache(maxEntries, maxBytes)
+	}
+}
+
+// decisionCache is a size-bounded LRU cache of governance decisions
+type decisionCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+// cacheEntry is a cached decision and its encoded size
+type cacheEntry struct {
+	key      string
+	decision *GovernanceDecision
+	size     int
+}
+
+// newDecisionCache creates an empty decision cache
+func newDecisionCache(maxEntries, maxBytes int) *decisionCache {
+	return &decisionCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// cacheKey derives the cache key for evaluating dctx against snap with cfg.
+// It returns false if the context cannot be hashed.
+func cacheKey(dctx *DeterministicContext, snap *registrySnapshot, cfg evaluationConfig) (string, bool) {
+	contextHash, err := dctx.Hash()
+	if err != nil {
+		return "", false
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"context":  contextHash,
+		"registry": snap.hash,
+		"versions": snap.versions,
+		"mode":     cfg.mode.String(),
+		"budget":   cfg.budget,
+	})
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), true
+}
+
+// get returns a copy of the cached decision for key, with its proof marked as a cache hit
+func (c *decisionCache) get(key string) *GovernanceDecision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	decision := elem.Value.(*cacheEntry).decision.clone()
+	decision.Proof.CacheHit = true
+	return decision
+}
+
+// put caches a copy of decision under key. Decisions affected by transient
+// failures (timeouts, cancellation) are never cached.
+func (c *decisionCache) put(key string, decision *GovernanceDecision) {
+	if !cacheable(decision) {
+		return
+	}
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return
+	}
+	size := len(data)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &cacheEntry{key: key, decision: decision.clone(), size: size}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove evicts elem. The caller must hold c.mu.
+func (c *decisionCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// purge evicts every entry
+func (c *decisionCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// len returns the number of cached decisions
+func (c *decisionCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// cacheable reports whether decision is a pure function of its inputs
+func cacheable(decision *GovernanceDecision) bool {
+	for _, sig := range decision.Signals {
+		meta, ok := sig["metadata"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch meta["failure"] {
+		case string(FailureTimeout), string(FailureCancelled):
+			return false
+		}
+	}
+	return true
+}
+
+// InvalidateCache evicts every cached decision
+func (ge *GovernanceEngine) InvalidateCache() {
+	if ge.cache != nil {
+		ge.cache.purge()
+	}
+}
+
+// CachedDecisions returns the number of cached decisions
+func (ge *GovernanceEngine) CachedDecisions() int {
+	if ge.cache == nil {
+		return 0
+	}
+	return ge.cache.len()
+}
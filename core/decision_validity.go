@@ -0,0 +1,150 @@
+/*
+Decision validity windows for GSAS.
+
+Every decision is valid for a window of logical time: the intersection of the
+windows of the primitives that allowed it. A primitive's window comes from its
+registration TTL, measured from the context's logical time, and from any
+valid_from/valid_until it returns. Enforcement refuses a decision outside its window.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// ValidityWindow is the half-open interval [From, Until) of logical time in
+// which a decision may be acted on. math.MinInt64 and math.MaxInt64 leave a side unbounded.
+type ValidityWindow struct {
+	From  int64 `json:"valid_from"`
+	Until int64 `json:"valid_until"`
+}
+
+// UnboundedValidity is the window of a decision that never expires
+var UnboundedValidity = ValidityWindow{From: math.MinInt64, Until: math.MaxInt64}
+
+// Contains reports whether logical time t falls within the window
+func (w ValidityWindow) Contains(t int64) bool {
+	return w.From <= t && t < w.Until
+}
+
+// Bounded reports whether either side of the window is bounded
+func (w ValidityWindow) Bounded() bool {
+	return w != UnboundedValidity
+}
+
+// Intersect returns the window in which both w and other are valid
+func (w ValidityWindow) Intersect(other ValidityWindow) ValidityWindow {
+	return ValidityWindow{From: max(w.From, other.From), Until: min(w.Until, other.Until)}
+}
+
+// WithValidity makes the primitive's permits valid for ttl ticks of logical
+// time from the logical time of the evaluated context
+func WithValidity(ttl int64) RegisterOption {
+	return func(r *registration) {
+		r.validity = ttl
+	}
+}
+
+// ttlWindow returns the window of a TTL starting at logical time at
+func ttlWindow(at, ttl int64) ValidityWindow {
+	if ttl <= 0 {
+		return UnboundedValidity
+	}
+	until := at + ttl
+	if until < at {
+		until = math.MaxInt64
+	}
+	return ValidityWindow{From: at, Until: until}
+}
+
+// resultValidity parses the valid_from and valid_until of a primitive result
+func resultValidity(result map[string]interface{}) (ValidityWindow, error) {
+	window := UnboundedValidity
+	// Bounds are parsed in a fixed order, so a malformed result always fails for the same reason
+	bounds := []struct {
+		key   string
+		bound *int64
+	}{{"valid_from", &window.From}, {"valid_until", &window.Until}}
+	for _, b := range bounds {
+		key, bound := b.key, b.bound
+		raw, ok := result[key]
+		if !ok || raw == nil {
+			continue
+		}
+		t, ok := logicalTimeValue(raw)
+		if !ok {
+			return window, fmt.Errorf("%s must be an integer logical time, got %T", key, raw)
+		}
+		*bound = t
+	}
+	return window, nil
+}
+
+// logicalTimeValue converts an integral numeric value to a logical time
+func logicalTimeValue(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case json.Number:
+		t, err := n.Int64()
+		return t, err == nil
+	}
+	return 0, false
+}
+
+// ValidityError is returned when a decision is enforced outside its validity window
+type ValidityError struct {
+	Now    int64
+	Window ValidityWindow
+}
+
+// Expired reports whether the decision's window has already closed
+func (e *ValidityError) Expired() bool {
+	return e.Now >= e.Window.Until
+}
+
+func (e *ValidityError) Error() string {
+	if e.Expired() {
+		return fmt.Sprintf("governance decision expired at logical time %d (now %d)", e.Window.Until, e.Now)
+	}
+	return fmt.Sprintf("governance decision not valid until logical time %d (now %d)", e.Window.From, e.Now)
+}
+
+// CheckValidity fails unless logical time now falls within the decision's validity window
+func (d *GovernanceDecision) CheckValidity(now int64) error {
+	if d == nil {
+		return fmt.Errorf("no governance decision")
+	}
+	if !d.Validity.Contains(now) {
+		return &ValidityError{Now: now, Window: d.Validity}
+	}
+	return nil
+}
+
+// EnforceAt fails closed unless the decision is a permit, valid at logical
+// time now, and every obligation attached to it is in acknowledged
+func (d *GovernanceDecision) EnforceAt(now int64, acknowledged ...string) error {
+	if err := d.EnforceObligations(acknowledged...); err != nil {
+		return err
+	}
+	return d.CheckValidity(now)
+}
@@ -5,41 +5,36 @@ Deterministic execution context for GSAS.
 package core
 
 import (
-	"encoding/json"
+	"bytes"
+	"crypto/sha256"
 	"fmt"
-	"sync"
+	"math/big"
 )
 
-// DeterministicContext represents an immutable, deterministic evaluation context
+// DeterministicContext represents an immutable, deterministic evaluation context.
+// Its data is frozen into persistent structures, so contexts derived from it
+// share that data and no locking is needed.
 type DeterministicContext struct {
-	data map[string]interface{}
-	time int
-	mu   sync.RWMutex
+	data     *pmap
+	time     int
+	upstream *UpstreamView
+	meter    *costMeter
+	limits   contextLimits
+	keys     int   // Map keys in data, counted against limits
+	size     int   // Size of data, counted against limits
+	err      error // Why the data was rejected, if it was
 }
 
-// NewDeterministicContext creates a new deterministic context
+// NewDeterministicContext creates a new deterministic context.
+// Data that fails validation is dropped and reported by Err, and the engine
+// denies any evaluation of the context; use BuildDeterministicContext to
+// handle the error when the context is built.
 func NewDeterministicContext(data map[string]interface{}, logicalTime int) *DeterministicContext {
-	frozenData := deepCopy(data)
-	return &DeterministicContext{
-		data: frozenData,
-		time: logicalTime,
-	}
-}
-
-// deepCopy creates a deep copy using JSON marshaling for true immutability
-func deepCopy(d map[string]interface{}) map[string]interface{} {
-	if d == nil {
-		return make(map[string]interface{})
-	}
-	bytes, err := json.Marshal(d)
+	dc, err := BuildDeterministicContext(data, logicalTime)
 	if err != nil {
-		return make(map[string]interface{})
-	}
-	var result map[string]interface{}
-	if err := json.Unmarshal(bytes, &result); err != nil {
-		return make(map[string]interface{})
+		return &DeterministicContext{data: emptyPmap, time: logicalTime, limits: defaultContextLimits(), err: err}
 	}
-	return result
+	return dc
 }
 
 // deepCopyValue recursively copies a value
@@ -61,6 +56,14 @@ func deepCopyValue(d interface{}) interface{} {
 			result[i] = deepCopyValue(val)
 		}
 		return result
+	case []byte:
+		return append([]byte{}, v...)
+	case *big.Int:
+		return new(big.Int).Set(v)
+	case []Obligation:
+		return cloneObligations(v)
+	case []Advice:
+		return cloneAdvice(v)
 	default:
 		return d
 	}
@@ -68,22 +71,74 @@ func deepCopyValue(d interface{}) interface{} {
 
 // Get retrieves a value from the context with default fallback
 func (dc *DeterministicContext) Get(key string, defaultValue interface{}) interface{} {
-	dc.mu.RLock()
-	defer dc.mu.RUnlock()
-	if val, exists := dc.data[key]; exists {
-		return deepCopyValue(val)
+	if val, exists := dc.data.get(key); exists {
+		return thaw(val)
 	}
 	return defaultValue
 }
 
 // Has checks if a key exists in the context
 func (dc *DeterministicContext) Has(key string) bool {
-	dc.mu.RLock()
-	defer dc.mu.RUnlock()
-	_, exists := dc.data[key]
+	_, exists := dc.data.get(key)
 	return exists
 }
 
+// Len returns the number of top-level keys in the context
+func (dc *DeterministicContext) Len() int {
+	return dc.data.Len()
+}
+
+// Keys returns the top-level keys of the context in sorted order
+func (dc *DeterministicContext) Keys() []string {
+	return dc.data.keys()
+}
+
+// with derives a context with the new key bound to value, sharing the rest of
+// dc's data. The value is validated against dc's limits.
+func (dc *DeterministicContext) with(key string, value interface{}) (*DeterministicContext, error) {
+	f := &freezer{limits: dc.limits, keys: dc.keys, size: dc.size}
+	path := &valuePath{key: key}
+	if err := f.addKey(path, key); err != nil {
+		return nil, err
+	}
+	frozen, err := f.freeze(value, path, 1)
+	if err != nil {
+		return nil, err
+	}
+	derived := *dc
+	derived.data, derived.keys, derived.size = dc.data.set(key, frozen), f.keys, f.size
+	return &derived, nil
+}
+
+// Upstream returns the read-only signals of the primitives being evaluated
+// depends on, or nil outside dependent evaluation
+func (dc *DeterministicContext) Upstream() *UpstreamView {
+	if dc == nil {
+		return nil
+	}
+	return dc.upstream
+}
+
+// withUpstream derives a context sharing dc's frozen data with an upstream view attached
+func (dc *DeterministicContext) withUpstream(view *UpstreamView) *DeterministicContext {
+	if dc == nil {
+		return &DeterministicContext{data: emptyPmap, limits: defaultContextLimits(), upstream: view}
+	}
+	derived := *dc
+	derived.upstream = view
+	return &derived
+}
+
+// withMeter derives a context sharing dc's frozen data that charges evaluation cost to meter
+func (dc *DeterministicContext) withMeter(meter *costMeter) *DeterministicContext {
+	if dc == nil {
+		return &DeterministicContext{data: emptyPmap, limits: defaultContextLimits(), meter: meter}
+	}
+	derived := *dc
+	derived.meter = meter
+	return &derived
+}
+
 // Time returns the logical time of the context
 func (dc *DeterministicContext) Time() int {
 	return dc.time
@@ -91,24 +146,35 @@ func (dc *DeterministicContext) Time() int {
 
 // Data returns a copy of the internal data map
 func (dc *DeterministicContext) Data() map[string]interface{} {
-	dc.mu.RLock()
-	defer dc.mu.RUnlock()
-	return deepCopy(dc.data)
+	return thawMap(dc.data)
+}
+
+// Hash returns the SHA256 hash of the type-tagged canonical encoding of the
+// context data and logical time
+func (dc *DeterministicContext) Hash() (string, error) {
+	if dc == nil {
+		return "", fmt.Errorf("nil context")
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "time:%d;data:", dc.time)
+	if err := writeCanonical(&buf, dc.data); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(buf.Bytes())), nil
 }
 
 // String returns a string representation of the context
 func (dc *DeterministicContext) String() string {
-	return fmt.Sprintf("DeterministicContext(time=%d, data=%v)", dc.time, dc.data)
+	return fmt.Sprintf("DeterministicContext(time=%d, data=%v)", dc.time, thawMap(dc.data))
 }
 
 // GetItem retrieves a value from the context by key
 func (dc *DeterministicContext) GetItem(key string) (interface{}, error) {
-	dc.mu.RLock()
-	defer dc.mu.RUnlock()
-	if val, exists := dc.data[key]; exists {
-		return deepCopyValue(val), nil
+	val, err := dc.lookup(key)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("key '%s' not found", key)
+	return thaw(val), nil
 }
 
 // SetItem prevents modification of the context (not implemented in Go due to immutability)
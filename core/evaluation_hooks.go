@@ -0,0 +1,180 @@
+/*
+Evaluation interceptors for GSAS.
+
+Interceptors plug cross-cutting behaviour (auditing, metrics, context
+enrichment, alerting) into the engine. They only ever see copies of engine
+state: they may add new context keys or veto an evaluation, but they can
+never mutate the context or turn a denial into a permit.
+*/
+
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// EvaluationInterceptor observes and enriches governance evaluation.
+// Interceptors run in the order they were added.
+type EvaluationInterceptor interface {
+	// BeforeEvaluation may return additional context data, merged into a derived
+	// context; existing keys cannot be overwritten. A non-nil error denies the evaluation.
+	BeforeEvaluation(ctx *DeterministicContext) (map[string]interface{}, error)
+
+	// BeforePrimitive is called before each primitive is evaluated
+	BeforePrimitive(id string, ctx *DeterministicContext)
+
+	// AfterPrimitive is called with a copy of each primitive's signal
+	AfterPrimitive(id string, signal map[string]interface{})
+
+	// AfterDecision is called with a copy of the final decision
+	AfterDecision(decision *GovernanceDecision)
+}
+
+// BaseInterceptor is a no-op EvaluationInterceptor to embed in partial implementations
+type BaseInterceptor struct{}
+
+func (BaseInterceptor) BeforeEvaluation(ctx *DeterministicContext) (map[string]interface{}, error) {
+	return nil, nil
+}
+func (BaseInterceptor) BeforePrimitive(id string, ctx *DeterministicContext)    {}
+func (BaseInterceptor) AfterPrimitive(id string, signal map[string]interface{}) {}
+func (BaseInterceptor) AfterDecision(decision *GovernanceDecision)              {}
+
+// AddInterceptor appends an interceptor to the engine's hook chain
+func (ge *GovernanceEngine) AddInterceptor(i EvaluationInterceptor) error {
+	if i == nil {
+		return fmt.Errorf("interceptor cannot be nil")
+	}
+
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+
+	var current []EvaluationInterceptor
+	if p := ge.interceptors.Load(); p != nil {
+		current = *p
+	}
+	chain := make([]EvaluationInterceptor, len(current), len(current)+1)
+	copy(chain, current)
+	chain = append(chain, i)
+	ge.interceptors.Store(&chain)
+
+	return nil
+}
+
+// hookChain runs the interceptors of one evaluation
+type hookChain []EvaluationInterceptor
+
+// hooks returns the interceptor chain current at the time of the call
+func (ge *GovernanceEngine) hooks() hookChain {
+	if p := ge.interceptors.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// beforeEvaluation runs every BeforeEvaluation hook, returning the enriched context.
+// A hook that errors, panics or overwrites an existing key denies the evaluation.
+func (h hookChain) beforeEvaluation(dctx *DeterministicContext) (*DeterministicContext, error) {
+	if len(h) == 0 {
+		return dctx, nil
+	}
+
+	for idx, i := range h {
+		extra, err := safeBeforeEvaluation(i, dctx)
+		if err != nil {
+			return dctx, fmt.Errorf("interceptor %d denied evaluation: %v", idx, err)
+		}
+		if len(extra) == 0 {
+			continue
+		}
+
+		if dctx == nil {
+			dctx = NewDeterministicContext(nil, 0)
+		}
+		enriched := dctx
+		for _, k := range sortedKeys(extra) {
+			if dctx.Has(k) {
+				return dctx, fmt.Errorf("interceptor %d denied evaluation: cannot overwrite context key '%s'", idx, k)
+			}
+			if enriched, err = enriched.with(k, extra[k]); err != nil {
+				return dctx, fmt.Errorf("interceptor %d denied evaluation: %v", idx, err)
+			}
+		}
+		dctx = enriched
+	}
+	return dctx, nil
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// safeBeforeEvaluation runs one BeforeEvaluation hook, recovering a panic as an error
+func safeBeforeEvaluation(i EvaluationInterceptor, dctx *DeterministicContext) (extra map[string]interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			extra, err = nil, fmt.Errorf("interceptor panicked: %v", v)
+		}
+	}()
+	return i.BeforeEvaluation(dctx)
+}
+
+// beforePrimitive runs every BeforePrimitive hook
+func (h hookChain) beforePrimitive(id string, dctx *DeterministicContext) {
+	for _, i := range h {
+		observe(func() { i.BeforePrimitive(id, dctx) })
+	}
+}
+
+// afterPrimitive runs every AfterPrimitive hook, each with its own copy of signal
+func (h hookChain) afterPrimitive(id string, signal map[string]interface{}) {
+	for _, i := range h {
+		copied := deepCopyValue(signal).(map[string]interface{})
+		observe(func() { i.AfterPrimitive(id, copied) })
+	}
+}
+
+// afterDecision runs every AfterDecision hook, each with its own copy of decision
+func (h hookChain) afterDecision(decision *GovernanceDecision) {
+	for _, i := range h {
+		copied := decision.clone()
+		observe(func() { i.AfterDecision(copied) })
+	}
+}
+
+// observe runs an observation hook. Observation hooks cannot influence the
+// decision, so a panic is recovered and discarded.
+func observe(hook func()) {
+	defer func() {
+		_ = recover()
+	}()
+	hook()
+}
+
+// clone returns a deep copy of the decision
+func (d *GovernanceDecision) clone() *GovernanceDecision {
+	if d == nil {
+		return nil
+	}
+	copied := *d
+	if d.Signals != nil {
+		copied.Signals = make([]map[string]interface{}, len(d.Signals))
+		for i, sig := range d.Signals {
+			copied.Signals[i] = deepCopyValue(sig).(map[string]interface{})
+		}
+	}
+	copied.FailureReasons = slices.Clone(d.FailureReasons)
+	copied.ShadowFailures = slices.Clone(d.ShadowFailures)
+	copied.Obligations = cloneObligations(d.Obligations)
+	copied.Advice = cloneAdvice(d.Advice)
+	copied.Proof = d.Proof.clone()
+	return &copied
+}
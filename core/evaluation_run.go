@@ -0,0 +1,233 @@
+/*
+Per-evaluation state for the GSAS governance engine.
+
+An evaluation run accumulates the signals, outcomes and conditions of one
+decision and assembles the decision and its proof once evaluation ends.
+*/
+
+package core
+
+// primitiveEvaluation is the result of evaluating one registered primitive
+type primitiveEvaluation struct {
+	reg         *registration
+	signal      map[string]interface{}
+	outcome     Outcome
+	obligations []Obligation
+	advice      []Advice
+	reason      string // Failure reason when the outcome does not allow execution
+	exhausted   bool   // The decision's cost budget ran out
+	validity    ValidityWindow
+}
+
+// newPrimitiveEvaluation interprets a primitive result and builds its signal
+func newPrimitiveEvaluation(reg *registration, result map[string]interface{}) *primitiveEvaluation {
+	obligations, advice, err := resultObligations(result, reg.id)
+	validity := UnboundedValidity
+	if err == nil {
+		validity, err = resultValidity(result)
+	}
+	if err != nil {
+		// Conditions the engine cannot understand must not be dropped silently
+		result = malformedFailure(err).withPrimitive(reg.id, reg.version).result()
+		obligations, advice, validity = nil, nil, UnboundedValidity
+	}
+	outcome := ResultOutcome(result)
+
+	signal := map[string]interface{}{
+		"primitive_id": reg.id,
+		"version":      reg.version,
+		"valid":        outcome.Allows(),
+		"outcome":      outcome,
+		"metadata":     result["metadata"],
+		"evidence":     result["evidence"],
+	}
+	if len(obligations) > 0 {
+		signal["obligations"] = obligations
+	}
+	if len(advice) > 0 {
+		signal["advice"] = advice
+	}
+	if reg.shadow {
+		signal["shadow"] = true
+	}
+
+	ev := &primitiveEvaluation{
+		reg:         reg,
+		signal:      signal,
+		outcome:     outcome,
+		obligations: obligations,
+		advice:      advice,
+		validity:    UnboundedValidity,
+	}
+	ev.restrict(validity)
+	if !outcome.Allows() {
+		ev.reason = failureReason(reg.id, outcome, result)
+	}
+	return ev
+}
+
+// restrict narrows the evaluation's validity window to w
+func (ev *primitiveEvaluation) restrict(w ValidityWindow) {
+	ev.validity = ev.validity.Intersect(w)
+	if ev.validity.Bounded() {
+		ev.signal["validity"] = ev.validity
+	}
+}
+
+// evaluationRun accumulates the state of a single decision
+type evaluationRun struct {
+	snap      *registrySnapshot
+	decision  *GovernanceDecision
+	outcomes  []Outcome
+	shadowIDs []string
+	skipped   []SkippedPrimitive
+	evaluated map[string]*primitiveEvaluation
+	edges     map[string][]string
+	meter     *costMeter
+	validity  ValidityWindow // Intersection of the windows of the allowing primitives
+}
+
+// newEvaluationRun starts a decision against snap
+func newEvaluationRun(snap *registrySnapshot) *evaluationRun {
+	return &evaluationRun{
+		snap: snap,
+		decision: &GovernanceDecision{
+			Signals:        make([]map[string]interface{}, 0, len(snap.registrations)),
+			FailureReasons: []string{},
+			ShadowFailures: []string{},
+			Obligations:    []Obligation{},
+			Advice:         []Advice{},
+		},
+		outcomes:  []Outcome{},
+		shadowIDs: []string{},
+		skipped:   []SkippedPrimitive{},
+		evaluated: make(map[string]*primitiveEvaluation),
+		edges:     make(map[string][]string),
+		validity:  UnboundedValidity,
+	}
+}
+
+// deny records a decision-level denial that is not attributable to a primitive
+func (r *evaluationRun) deny(reason string) {
+	r.outcomes = append(r.outcomes, Deny)
+	r.decision.FailureReasons = append(r.decision.FailureReasons, reason)
+}
+
+// route returns the registrations whose scope matches dctx in evaluation order,
+// recording the rest as skipped. It fails closed when routing leaves no enforcing primitive.
+func (r *evaluationRun) route(dctx *DeterministicContext) []*registration {
+	applicable := make([]*registration, 0, len(r.snap.order))
+	gating, applicableGating := 0, 0
+	for _, reg := range r.snap.order {
+		if !reg.shadow {
+			gating++
+		}
+		if ok, why := reg.scope.applies(dctx); !ok {
+			r.skipped = append(r.skipped, SkippedPrimitive{PrimitiveID: reg.id, Reason: why})
+			continue
+		}
+		if !reg.shadow {
+			applicableGating++
+		}
+		applicable = append(applicable, reg)
+	}
+
+	if gating > 0 && applicableGating == 0 {
+		r.deny(noPrimitivesReason(dctx))
+	}
+	return applicable
+}
+
+// record adds a primitive evaluation to the decision
+func (r *evaluationRun) record(ev *primitiveEvaluation) {
+	d := r.decision
+	d.Signals = append(d.Signals, ev.signal)
+	r.evaluated[ev.reg.id] = ev
+	if len(ev.reg.dependencies) > 0 {
+		r.edges[ev.reg.id] = ev.reg.dependencies
+	}
+
+	if ev.reg.shadow {
+		r.shadowIDs = append(r.shadowIDs, ev.reg.id)
+		if !ev.outcome.Allows() {
+			d.ShadowFailures = append(d.ShadowFailures, ev.reason)
+		}
+		return
+	}
+
+	r.outcomes = append(r.outcomes, ev.outcome)
+	if ev.outcome == Permit {
+		d.Obligations = append(d.Obligations, ev.obligations...)
+	}
+	d.Advice = append(d.Advice, ev.advice...)
+	if !ev.outcome.Allows() {
+		d.FailureReasons = append(d.FailureReasons, ev.reason)
+		return
+	}
+	r.validity = r.validity.Intersect(ev.validity)
+}
+
+// finish combines the outcomes and generates the proof, stamped by clock
+func (r *evaluationRun) finish(pg *ProofGenerator, clock Clock) *GovernanceDecision {
+	d := r.decision
+
+	// Fail closed: evaluated gating primitives must include a permit, not only abstentions
+	if len(r.outcomes) > 0 && CombineOutcomes(r.outcomes...) == NotApplicable {
+		r.deny("No applicable primitive permitted the action")
+	}
+
+	// Indeterminate and Deny both fail closed
+	d.Outcome = CombineOutcomes(r.outcomes...)
+	d.Permitted = d.Outcome.Allows()
+	if !d.Permitted {
+		// Obligations only condition a permit
+		d.Obligations = []Obligation{}
+	}
+
+	// Generate proof
+	evaluatedIDs := make([]string, len(d.Signals))
+	for i, sig := range d.Signals {
+		evaluatedIDs[i] = sig["primitive_id"].(string)
+	}
+	versions := r.snap.versionsCopy()
+
+	d.Proof = pg.GenerateProofWithClock(d.Permitted, evaluatedIDs, d.Signals, versions, clock)
+	d.Proof.Outcome = d.Outcome
+	d.Proof.RegistryEpoch = r.snap.epoch
+	d.Proof.RegistryHash = r.snap.hash
+	if len(r.shadowIDs) > 0 {
+		d.Proof.ShadowPrimitives = r.shadowIDs
+	}
+	if len(r.skipped) > 0 {
+		d.Proof.SkippedPrimitives = r.skipped
+	}
+	if len(r.edges) > 0 {
+		d.Proof.DependencyEdges = r.edges
+	}
+	for _, reg := range r.snap.registrations {
+		if reg.level == nil {
+			continue
+		}
+		if d.Proof.PrimitiveLevels == nil {
+			d.Proof.PrimitiveLevels = make(map[string]PolicyLevel)
+		}
+		d.Proof.PrimitiveLevels[reg.id] = *reg.level
+	}
+	d.Validity = r.validity
+	if r.validity.Bounded() {
+		window := r.validity
+		d.Proof.Validity = &window
+	}
+	if r.meter != nil {
+		d.Proof.CostBudget = r.meter.budget
+		d.Proof.CostConsumed, _ = r.meter.state()
+	}
+	for _, o := range d.Obligations {
+		d.Proof.ObligationCommitments = append(d.Proof.ObligationCommitments, pg.CommitObligation(o))
+	}
+	for _, a := range d.Advice {
+		d.Proof.AdviceCommitments = append(d.Proof.AdviceCommitments, pg.CommitAdvice(a))
+	}
+
+	return d
+}
@@ -0,0 +1,141 @@
+/*
+Pre-execution gate for GSAS.
+
+A Gate evaluates governance before running an action and runs it only on a
+valid permit whose obligations are acknowledged. Every attempt produces an
+execution receipt that binds the proof's commitment hash to what happened,
+so each executed side effect is provably preceded by a permit.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// Action is a side effect guarded by a Gate
+type Action func(ctx context.Context) (interface{}, error)
+
+// Evaluator produces governance decisions. GovernanceEngine implements it.
+type Evaluator interface {
+	EvaluateContext(ctx context.Context, dctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision
+}
+
+// ExecutionReceipt records one gated execution attempt and the proof it relied on
+type ExecutionReceipt struct {
+	ProofHash   string    `json:"proof_hash"`             // CommitmentHash of the decision's proof
+	Permitted   bool      `json:"permitted"`              // Whether the gate allowed the action to run
+	Executed    bool      `json:"executed"`               // Whether the action ran
+	OutcomeHash string    `json:"outcome_hash,omitempty"` // SHA256 of the action's result
+	Error       string    `json:"error,omitempty"`        // Why the gate refused, or the action's error
+	StartedAt   int64     `json:"started_at"`
+	CompletedAt int64     `json:"completed_at"`
+	ClockKind   ClockKind `json:"clock_kind"`
+}
+
+// CommitmentHash returns the SHA256 hash of the canonical encoding of the receipt
+func (r *ExecutionReceipt) CommitmentHash() string {
+	data, _ := json.Marshal(r)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// GateRefusedError is returned when the gate does not run an action
+type GateRefusedError struct {
+	Decision *GovernanceDecision
+	Reason   error
+}
+
+func (e *GateRefusedError) Error() string {
+	return fmt.Sprintf("execution refused: %v", e.Reason)
+}
+
+func (e *GateRefusedError) Unwrap() error {
+	return e.Reason
+}
+
+// GateOption configures a Gate
+type GateOption func(*Gate)
+
+// WithReceiptClock sets the clock that stamps execution receipts. The default is WallClock.
+func WithReceiptClock(clock Clock) GateOption {
+	return func(g *Gate) {
+		if clock != nil {
+			g.clock = clock
+		}
+	}
+}
+
+// WithGateEvaluation passes opts to every evaluation the gate makes
+func WithGateEvaluation(opts ...EvaluateOption) GateOption {
+	return func(g *Gate) {
+		g.evaluateOpts = append(g.evaluateOpts, opts...)
+	}
+}
+
+// Gate runs actions only when governance permits them
+type Gate struct {
+	evaluator    Evaluator
+	clock        Clock
+	evaluateOpts []EvaluateOption
+}
+
+// NewGate creates a gate that consults evaluator before every action
+func NewGate(evaluator Evaluator, opts ...GateOption) *Gate {
+	g := &Gate{evaluator: evaluator, clock: WallClock{}}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Execute evaluates dctx and runs action only if the decision is a permit, valid
+// at the context's logical time, with every obligation in acknowledged.
+// It returns the action's result, the receipt of the attempt, and a
+// *GateRefusedError if the action did not run or the action's own error.
+func (g *Gate) Execute(ctx context.Context, dctx *DeterministicContext, action Action, acknowledged ...string) (interface{}, *ExecutionReceipt, error) {
+	receipt := &ExecutionReceipt{StartedAt: g.clock.Now(), ClockKind: g.clock.Kind()}
+	finish := func(err error) error {
+		if err != nil {
+			receipt.Error = err.Error()
+		}
+		receipt.CompletedAt = g.clock.Now()
+		return err
+	}
+
+	if action == nil {
+		return nil, receipt, finish(&GateRefusedError{Reason: fmt.Errorf("no action to execute")})
+	}
+	if dctx == nil {
+		return nil, receipt, finish(&GateRefusedError{Reason: fmt.Errorf("no context to evaluate")})
+	}
+	decision := g.evaluator.EvaluateContext(ctx, dctx, g.evaluateOpts...)
+	if decision == nil || decision.Proof == nil {
+		return nil, receipt, finish(&GateRefusedError{Decision: decision, Reason: fmt.Errorf("no governance proof")})
+	}
+	receipt.ProofHash = decision.Proof.CommitmentHash()
+
+	if err := decision.EnforceAt(int64(dctx.Time()), acknowledged...); err != nil {
+		return nil, receipt, finish(&GateRefusedError{Decision: decision, Reason: err})
+	}
+	receipt.Permitted = true
+
+	result, err := runAction(ctx, action)
+	receipt.Executed = true
+	if err == nil {
+		receipt.OutcomeHash = commitJSON(result)
+	}
+	return result, receipt, finish(err)
+}
+
+// runAction runs action, converting a panic into an error
+func runAction(ctx context.Context, action Action) (result interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			result, err = nil, fmt.Errorf("action panicked: %v", v)
+		}
+	}()
+	return action(ctx)
+}
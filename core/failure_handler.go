@@ -0,0 +1,149 @@
+/*
+Failure handling for GSAS governance evaluation.
+
+Converts primitives that cannot produce a result of their own (timeouts,
+cancellation, panics) into structured, fail-closed denial signals.
+*/
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FailureKind classifies why a governance signal failed
+type FailureKind string
+
+const (
+	// FailureDenied means the primitive evaluated and denied the action
+	FailureDenied FailureKind = "denied"
+	// FailureTimeout means the primitive or the decision exceeded its deadline
+	FailureTimeout FailureKind = "timeout"
+	// FailureCancelled means the caller cancelled evaluation
+	FailureCancelled FailureKind = "cancelled"
+	// FailurePanic means the primitive panicked during evaluation
+	FailurePanic FailureKind = "panic"
+	// FailureMalformedResult means the primitive returned a result the engine cannot interpret
+	FailureMalformedResult FailureKind = "malformed_result"
+	// FailureDependency means a primitive this one depends on did not permit
+	FailureDependency FailureKind = "dependency_failed"
+)
+
+// evaluationFailure describes a primitive that produced no usable result
+type evaluationFailure struct {
+	kind    FailureKind
+	reason  string
+	details map[string]interface{}
+}
+
+// result converts the failure into a fail-closed, Indeterminate primitive result
+func (f *evaluationFailure) result() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"reason":  f.reason,
+		"failure": string(f.kind),
+	}
+	for k, v := range f.details {
+		metadata[k] = v
+	}
+	return map[string]interface{}{
+		"valid":    false,
+		"outcome":  Indeterminate,
+		"metadata": metadata,
+		"evidence": []interface{}{},
+	}
+}
+
+// withPrimitive records the failing primitive's ID and version in the failure details
+func (f *evaluationFailure) withPrimitive(id, version string) *evaluationFailure {
+	details := make(map[string]interface{}, len(f.details)+2)
+	for k, v := range f.details {
+		details[k] = v
+	}
+	details["primitive_id"] = id
+	details["version"] = version
+	return &evaluationFailure{kind: f.kind, reason: f.reason, details: details}
+}
+
+// panicFailure converts a recovered panic value into an evaluation failure
+func panicFailure(v interface{}) *evaluationFailure {
+	return &evaluationFailure{
+		kind:    FailurePanic,
+		reason:  fmt.Sprintf("primitive panicked: %v", v),
+		details: map[string]interface{}{"panic": fmt.Sprint(v)},
+	}
+}
+
+// safeEvaluate evaluates p against input, recovering any panic as a failure
+func safeEvaluate(p GovernancePrimitive, input interface{}) (result map[string]interface{}, failure *evaluationFailure) {
+	defer func() {
+		if v := recover(); v != nil {
+			result = nil
+			failure = panicFailure(v)
+		}
+	}()
+	return p.Evaluate(input), nil
+}
+
+// contextFailure maps a done context to an evaluation failure.
+// parent is the decision-level context, used to tell a primitive timeout
+// apart from the whole decision running out of time.
+func contextFailure(parent context.Context, err error, timeout time.Duration) *evaluationFailure {
+	if errors.Is(err, context.Canceled) {
+		return &evaluationFailure{
+			kind:   FailureCancelled,
+			reason: "evaluation cancelled",
+		}
+	}
+	if parent.Err() == nil && timeout > 0 {
+		return &evaluationFailure{
+			kind:    FailureTimeout,
+			reason:  fmt.Sprintf("primitive timed out after %s", timeout),
+			details: map[string]interface{}{"timeout": timeout.String()},
+		}
+	}
+	return &evaluationFailure{
+		kind:   FailureTimeout,
+		reason: "decision deadline exceeded",
+	}
+}
+
+// invokePrimitive evaluates p against input, bounded by ctx and timeout.
+// A primitive that has not returned when the deadline passes is abandoned;
+// its eventual result is discarded.
+func invokePrimitive(ctx context.Context, p GovernancePrimitive, input interface{}, timeout time.Duration) (map[string]interface{}, *evaluationFailure) {
+	if err := ctx.Err(); err != nil {
+		return nil, contextFailure(ctx, err, 0)
+	}
+
+	// No deadline and no way to cancel: evaluate inline
+	if timeout <= 0 && ctx.Done() == nil {
+		return safeEvaluate(p, input)
+	}
+
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type evaluated struct {
+		result  map[string]interface{}
+		failure *evaluationFailure
+	}
+	done := make(chan evaluated, 1)
+	go func() {
+		result, failure := safeEvaluate(p, input)
+		done <- evaluated{result, failure}
+	}()
+
+	select {
+	case e := <-done:
+		return e.result, e.failure
+	case <-ctx.Done():
+		return nil, contextFailure(parent, ctx.Err(), timeout)
+	}
+}
@@ -8,187 +8,488 @@ fails closed on violations, and emits structured proofs.
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // GovernanceDecision represents the result of governance evaluation
 type GovernanceDecision struct {
 	Permitted      bool                     `json:"permitted"`
+	Outcome        Outcome                  `json:"outcome"`
 	Signals        []map[string]interface{} `json:"signals"`
 	FailureReasons []string                 `json:"failure_reasons"`
+	ShadowFailures []string                 `json:"shadow_failures"`
+	Obligations    []Obligation             `json:"obligations"`
+	Advice         []Advice                 `json:"advice"`
+	Validity       ValidityWindow           `json:"validity"` // Logical time in which the decision may be acted on
 	Proof          *GovernanceProof         `json:"proof"`
 }
 
+// registration holds a registered primitive and its per-primitive settings
+type registration struct {
+	id           string
+	primitive    GovernancePrimitive
+	version      string
+	timeout      time.Duration
+	shadow       bool
+	scope        Scope
+	dependencies []string
+	validity     int64        // TTL of the primitive's permits in logical time; zero is unbounded
+	level        *PolicyLevel // Hierarchy level that registered the primitive, if any
+	schemas      []*compiledSchema
+}
+
+// RegisterOption configures a single primitive registration
+type RegisterOption func(*registration)
+
+// WithTimeout bounds evaluation of the registered primitive,
+// overriding the engine-wide primitive timeout
+func WithTimeout(d time.Duration) RegisterOption {
+	return func(r *registration) {
+		r.timeout = d
+	}
+}
+
+// AsShadow registers the primitive in advisory mode: it is evaluated and recorded
+// in the decision and proof, but its result never affects Permitted
+func AsShadow() RegisterOption {
+	return func(r *registration) {
+		r.shadow = true
+	}
+}
+
+// EvaluationMode controls whether evaluation stops at the first failing primitive
+type EvaluationMode int
+
+const (
+	// FailFast stops evaluation at the first failing primitive (default)
+	FailFast EvaluationMode = iota
+	// Exhaustive evaluates every primitive and reports every failure
+	Exhaustive
+)
+
+// String returns the name of the evaluation mode
+func (m EvaluationMode) String() string {
+	switch m {
+	case FailFast:
+		return "fail_fast"
+	case Exhaustive:
+		return "exhaustive"
+	default:
+		return fmt.Sprintf("mode_%d", int(m))
+	}
+}
+
+// evaluationConfig holds per-call evaluation settings
+type evaluationConfig struct {
+	mode      EvaluationMode
+	requestID string
+	tenant    string // Tenant path scoping requestID, if evaluated through a hierarchy
+	budget    uint64
+	clock     Clock
+}
+
+// EvaluateOption configures a single evaluation call
+type EvaluateOption func(*evaluationConfig)
+
+// WithMode overrides the engine's default evaluation mode for one call
+func WithMode(mode EvaluationMode) EvaluateOption {
+	return func(c *evaluationConfig) {
+		c.mode = mode
+	}
+}
+
+// EngineOption configures a GovernanceEngine
+type EngineOption func(*GovernanceEngine)
+
+// WithPrimitiveTimeout bounds evaluation of every primitive that has no timeout of its own
+func WithPrimitiveTimeout(d time.Duration) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.primitiveTimeout = d
+	}
+}
+
+// WithDecisionTimeout bounds evaluation of the whole decision
+func WithDecisionTimeout(d time.Duration) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.decisionTimeout = d
+	}
+}
+
+// WithDefaultMode sets the evaluation mode used when a call does not override it
+func WithDefaultMode(mode EvaluationMode) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.mode = mode
+	}
+}
+
 // GovernanceEngine evaluates governance primitives in sequence
 type GovernanceEngine struct {
-	primitives   []GovernancePrimitive
-	primitiveIDs []string
-	versions     map[string]string
-	mu           sync.RWMutex
+	registry     atomic.Pointer[registrySnapshot]
+	interceptors atomic.Pointer[[]EvaluationInterceptor]
+	mu           sync.Mutex // serialises registry and interceptor writers
 	proofGen     *ProofGenerator
+
+	primitiveTimeout time.Duration
+	decisionTimeout  time.Duration
+	mode             EvaluationMode
+	batchWorkers     int
+	parallelism      int
+	cache            *decisionCache
+	idempotency      *idempotencyStore
+	idempotencyClock Clock
+	costBudget       uint64
 }
 
 // NewGovernanceEngine creates a new governance engine
-func NewGovernanceEngine() *GovernanceEngine {
-	return &GovernanceEngine{
-		primitives:   []GovernancePrimitive{},
-		primitiveIDs: []string{},
-		versions:     make(map[string]string),
-		proofGen:     &ProofGenerator{},
+func NewGovernanceEngine(opts ...EngineOption) *GovernanceEngine {
+	ge := &GovernanceEngine{
+		proofGen: &ProofGenerator{},
+	}
+	empty, _ := newRegistrySnapshot(nil, 0)
+	ge.registry.Store(empty)
+	for _, opt := range opts {
+		opt(ge)
 	}
+	return ge
 }
 
-// RegisterPrimitive registers a governance primitive with an ID
-func (ge *GovernanceEngine) RegisterPrimitive(id string, p GovernancePrimitive) error {
+// snapshot returns the current immutable registry snapshot
+func (ge *GovernanceEngine) snapshot() *registrySnapshot {
+	return ge.registry.Load()
+}
+
+// commit publishes registrations as the next registry snapshot.
+// The caller must hold ge.mu.
+func (ge *GovernanceEngine) commit(registrations []*registration) error {
+	next, err := ge.snapshot().with(registrations)
+	if err != nil {
+		return err
+	}
+	ge.registry.Store(next)
+	// Cache keys include the registry hash; purging frees entries that can no longer match
+	ge.InvalidateCache()
+	return nil
+}
+
+// newRegistration validates p and builds its registration
+func newRegistration(id string, p GovernancePrimitive, opts []RegisterOption) (*registration, error) {
 	if p == nil {
-		return errors.New("primitive cannot be nil")
+		return nil, errors.New("primitive cannot be nil")
 	}
 	if id == "" {
-		return errors.New("primitive ID cannot be empty")
+		return nil, errors.New("primitive ID cannot be empty")
+	}
+	schemas, err := declaredSchemas(p)
+	if err != nil {
+		return nil, fmt.Errorf("primitive '%s' declares an invalid context schema: %w", id, err)
+	}
+	reg := &registration{
+		id:           id,
+		primitive:    p,
+		version:      p.Version(),
+		dependencies: declaredDependencies(p),
+		schemas:      schemas,
+	}
+	for _, opt := range opts {
+		opt(reg)
+	}
+	if err := reg.scope.validate(); err != nil {
+		return nil, fmt.Errorf("primitive '%s' has an invalid scope: %w", id, err)
+	}
+	return reg, nil
+}
+
+// RegisterPrimitive registers a governance primitive with an ID
+func (ge *GovernanceEngine) RegisterPrimitive(id string, p GovernancePrimitive, opts ...RegisterOption) error {
+	reg, err := newRegistration(id, p, opts)
+	if err != nil {
+		return err
 	}
 
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 
 	// Check for duplicate ID
-	for _, existingID := range ge.primitiveIDs {
-		if existingID == id {
-			return fmt.Errorf("primitive with ID '%s' already registered", id)
-		}
+	current := ge.snapshot()
+	if current.indexOf(id) >= 0 {
+		return fmt.Errorf("primitive with ID '%s' already registered", id)
 	}
 
-	ge.primitives = append(ge.primitives, p)
-	ge.primitiveIDs = append(ge.primitiveIDs, id)
-	ge.versions[id] = p.Version()
+	registrations := make([]*registration, len(current.registrations), len(current.registrations)+1)
+	copy(registrations, current.registrations)
+	return ge.commit(append(registrations, reg))
+}
 
-	return nil
+// UnregisterPrimitive removes the primitive registered under id
+func (ge *GovernanceEngine) UnregisterPrimitive(id string) error {
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+
+	current := ge.snapshot()
+	idx := current.indexOf(id)
+	if idx < 0 {
+		return fmt.Errorf("primitive with ID '%s' not registered", id)
+	}
+
+	registrations := make([]*registration, 0, len(current.registrations)-1)
+	registrations = append(registrations, current.registrations[:idx]...)
+	registrations = append(registrations, current.registrations[idx+1:]...)
+	return ge.commit(registrations)
+}
+
+// ReplacePrimitive swaps the primitive registered under id, keeping its evaluation position.
+// Registration options are not inherited from the replaced primitive.
+func (ge *GovernanceEngine) ReplacePrimitive(id string, p GovernancePrimitive, opts ...RegisterOption) error {
+	reg, err := newRegistration(id, p, opts)
+	if err != nil {
+		return err
+	}
+
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+
+	current := ge.snapshot()
+	idx := current.indexOf(id)
+	if idx < 0 {
+		return fmt.Errorf("primitive with ID '%s' not registered", id)
+	}
+
+	registrations := make([]*registration, len(current.registrations))
+	copy(registrations, current.registrations)
+	registrations[idx] = reg
+	return ge.commit(registrations)
+}
+
+// RegistryEpoch returns the registry epoch, incremented on every registry change
+func (ge *GovernanceEngine) RegistryEpoch() uint64 {
+	return ge.snapshot().epoch
+}
+
+// RegistryHash returns the SHA256 hash of the current registry configuration
+func (ge *GovernanceEngine) RegistryHash() string {
+	return ge.snapshot().hash
 }
 
 // Evaluate evaluates all registered primitives against context
 // Fails closed: any failure results in denial
-func (ge *GovernanceEngine) Evaluate(ctx *DeterministicContext) *GovernanceDecision {
-	ge.mu.RLock()
-	defer ge.mu.RUnlock()
-
-	decision := &GovernanceDecision{
-		Permitted:      true,
-		Signals:        make([]map[string]interface{}, 0, len(ge.primitives)),
-		FailureReasons: []string{},
-	}
-
-	// Evaluate each primitive in strict sequence
-	for i, primitive := range ge.primitives {
-		id := ge.primitiveIDs[i]
-		result := primitive.Evaluate(ctx)
-
-		signal := map[string]interface{}{
-			"primitive_id": id,
-			"version":      ge.versions[id],
-			"valid":        result["valid"],
-			"metadata":     result["metadata"],
-			"evidence":     result["evidence"],
-		}
-		decision.Signals = append(decision.Signals, signal)
-
-		valid, ok := result["valid"].(bool)
-		if !ok || !valid {
-			decision.Permitted = false
-			reason := fmt.Sprintf("Primitive '%s' failed", id)
-			if meta, ok := result["metadata"].(map[string]interface{}); ok {
-				if r, ok := meta["reason"].(string); ok {
-					reason = fmt.Sprintf("Primitive '%s' failed: %s", id, r)
-				}
+func (ge *GovernanceEngine) Evaluate(ctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision {
+	return ge.EvaluateContext(context.Background(), ctx, opts...)
+}
+
+// EvaluateContext evaluates all registered primitives against dctx, bounded by ctx.
+// A primitive that times out or is cancelled produces a fail-closed denial signal.
+func (ge *GovernanceEngine) EvaluateContext(ctx context.Context, dctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision {
+	return ge.evaluateSnapshot(ctx, ge.snapshot(), dctx, opts...)
+}
+
+// EvaluateWithLogicalTime evaluates with explicit logical time (for deterministic testing)
+//
+// Deprecated: use Evaluate with AtLogicalTime.
+func (ge *GovernanceEngine) EvaluateWithLogicalTime(ctx *DeterministicContext, logicalTime int64) *GovernanceDecision {
+	return ge.Evaluate(ctx, AtLogicalTime(logicalTime))
+}
+
+// evaluateSnapshot evaluates dctx against snap, deduplicating by request ID when configured
+func (ge *GovernanceEngine) evaluateSnapshot(ctx context.Context, snap *registrySnapshot, dctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision {
+	cfg := ge.config(opts)
+	if ge.decisionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ge.decisionTimeout)
+		defer cancel()
+	}
+
+	hooks := ge.hooks()
+	// A context whose data was rejected is never evaluated
+	if err := dctx.Err(); err != nil {
+		return ge.deny(snap, cfg.clock, hooks, fmt.Sprintf("%s: %v", FailureInvalidContext, err))
+	}
+	if cfg.requestID != "" && ge.idempotency != nil {
+		return ge.evaluateIdempotent(ctx, snap, dctx, cfg, hooks)
+	}
+	return ge.decide(ctx, snap, dctx, cfg, hooks)
+}
+
+// config resolves the settings of one call from the engine defaults and opts
+func (ge *GovernanceEngine) config(opts []EvaluateOption) evaluationConfig {
+	cfg := evaluationConfig{mode: ge.mode, budget: ge.costBudget, clock: ge.proofGen.Clock()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// decide runs every primitive of snap in strict sequence and generates the proof.
+// Primitives run in registration order, except that dependencies always run first.
+func (ge *GovernanceEngine) decide(ctx context.Context, snap *registrySnapshot, dctx *DeterministicContext, cfg evaluationConfig, hooks hookChain) *GovernanceDecision {
+	dctx, err := hooks.beforeEvaluation(dctx)
+	if err != nil {
+		return ge.deny(snap, cfg.clock, hooks, err.Error())
+	}
+
+	var key string
+	cached := false
+	if ge.cache != nil {
+		if key, cached = cacheKey(dctx, snap, cfg); cached {
+			if decision := ge.cache.get(key); decision != nil {
+				hooks.afterDecision(decision)
+				return decision
 			}
-			decision.FailureReasons = append(decision.FailureReasons, reason)
-			// Fail closed: stop on first failure
-			break
 		}
 	}
 
-	// Generate proof
-	evaluatedIDs := make([]string, len(decision.Signals))
-	for i, sig := range decision.Signals {
-		evaluatedIDs[i] = sig["primitive_id"].(string)
+	// Evaluate each primitive in strict sequence.
+	// Shadow primitives are still evaluated after a fail-fast stop.
+	run := newEvaluationRun(snap)
+	run.meter = newCostMeter(cfg.budget)
+	// Gating primitives charge the decision's budget; shadow primitives are not metered
+	metered, unmetered := dctx.withMeter(run.meter), dctx.withMeter(nil)
+	applicable := run.route(dctx)
+	// Schema violations are found before any primitive is evaluated and recorded
+	// in evaluation order; fail-fast evaluates no gating primitive after one
+	violated, gatingViolated := checkSchemas(applicable, dctx)
+	stopped := gatingViolated && cfg.mode == FailFast
+	pending := make([]*registration, 0, len(applicable))
+	for _, reg := range applicable {
+		if violated[reg] == nil && (reg.shadow || !stopped) {
+			pending = append(pending, reg)
+		}
 	}
+	prefetched := ge.prefetch(ctx, pending, unmetered, cfg.mode, run.meter != nil)
+	for _, reg := range applicable {
+		if failure := violated[reg]; failure != nil {
+			ev := newPrimitiveEvaluation(reg, failure.result())
+			run.record(ev)
+			hooks.afterPrimitive(reg.id, ev.signal)
+			continue
+		}
+		if stopped && !reg.shadow {
+			continue
+		}
 
-	decision.Proof = ge.proofGen.GenerateProof(
-		decision.Permitted,
-		evaluatedIDs,
-		decision.Signals,
-		ge.versions,
-	)
+		input := metered
+		if reg.shadow {
+			input = unmetered
+		}
+		hooks.beforePrimitive(reg.id, input)
+		// Independent primitives may already have been evaluated concurrently
+		ev := run.takePrefetched(prefetched, reg)
+		if ev == nil {
+			ev = ge.evaluateInRun(ctx, run, reg, input)
+		}
+		run.record(ev)
+		hooks.afterPrimitive(reg.id, ev.signal)
+
+		// An exhausted budget ends evaluation, whatever the mode
+		if ev.exhausted {
+			break
+		}
+		// Fail closed: stop on first failure unless every failure is wanted
+		if !ev.outcome.Allows() && !reg.shadow && cfg.mode == FailFast {
+			stopped = true
+		}
+	}
 
+	decision := run.finish(ge.proofGen, cfg.clock)
+	if cached {
+		ge.cache.put(key, decision)
+	}
+	hooks.afterDecision(decision)
 	return decision
 }
 
-// EvaluateWithLogicalTime evaluates with explicit logical time (for deterministic testing)
-func (ge *GovernanceEngine) EvaluateWithLogicalTime(ctx *DeterministicContext, logicalTime int64) *GovernanceDecision {
-	ge.mu.RLock()
-	defer ge.mu.RUnlock()
+// deny generates a denial against snap without evaluating any primitive
+func (ge *GovernanceEngine) deny(snap *registrySnapshot, clock Clock, hooks hookChain, reason string) *GovernanceDecision {
+	run := newEvaluationRun(snap)
+	run.deny(reason)
+	decision := run.finish(ge.proofGen, clock)
+	hooks.afterDecision(decision)
+	return decision
+}
 
-	decision := &GovernanceDecision{
-		Permitted:      true,
-		Signals:        make([]map[string]interface{}, 0, len(ge.primitives)),
-		FailureReasons: []string{},
+// evaluateInRun evaluates reg within run, exposing its dependencies' signals
+// and failing it closed if any dependency did not permit
+func (ge *GovernanceEngine) evaluateInRun(ctx context.Context, run *evaluationRun, reg *registration, dctx *DeterministicContext) *primitiveEvaluation {
+	if len(reg.dependencies) == 0 {
+		return ge.evaluatePrimitive(ctx, reg, dctx)
 	}
+	view, failure := run.upstream(reg)
+	if failure != nil {
+		return newPrimitiveEvaluation(reg, failure.withPrimitive(reg.id, reg.version).result())
+	}
+	return ge.evaluatePrimitive(ctx, reg, dctx.withUpstream(view))
+}
 
-	for i, primitive := range ge.primitives {
-		id := ge.primitiveIDs[i]
-		result := primitive.Evaluate(ctx)
+// evaluatePrimitive evaluates one registered primitive and builds its signal
+func (ge *GovernanceEngine) evaluatePrimitive(ctx context.Context, reg *registration, dctx *DeterministicContext) *primitiveEvaluation {
+	timeout := reg.timeout
+	if timeout <= 0 {
+		timeout = ge.primitiveTimeout
+	}
 
-		signal := map[string]interface{}{
-			"primitive_id": id,
-			"version":      ge.versions[id],
-			"valid":        result["valid"],
-			"metadata":     result["metadata"],
-			"evidence":     result["evidence"],
-		}
-		decision.Signals = append(decision.Signals, signal)
-
-		valid, ok := result["valid"].(bool)
-		if !ok || !valid {
-			decision.Permitted = false
-			reason := fmt.Sprintf("Primitive '%s' failed", id)
-			if meta, ok := result["metadata"].(map[string]interface{}); ok {
-				if r, ok := meta["reason"].(string); ok {
-					reason = fmt.Sprintf("Primitive '%s' failed: %s", id, r)
-				}
-			}
-			decision.FailureReasons = append(decision.FailureReasons, reason)
-			break
+	meter := dctx.meter
+	if meter != nil {
+		if _, ok := reg.primitive.(compositePrimitive); !ok && !meter.charge(primitiveCost(reg.primitive)) {
+			return exhaustedEvaluation(reg, meter)
 		}
 	}
 
-	evaluatedIDs := make([]string, len(decision.Signals))
-	for i, sig := range decision.Signals {
-		evaluatedIDs[i] = sig["primitive_id"].(string)
+	result, failure := invokePrimitive(ctx, reg.primitive, dctx, timeout)
+	if failure != nil {
+		result = failure.withPrimitive(reg.id, reg.version).result()
+	}
+	if meter != nil {
+		// A composite that ran out of budget part-way fails closed, whatever it returned
+		if _, exhausted := meter.state(); exhausted {
+			return exhaustedEvaluation(reg, meter)
+		}
 	}
+	ev := newPrimitiveEvaluation(reg, result)
+	ev.restrict(ttlWindow(int64(dctx.Time()), reg.validity))
+	return ev
+}
 
-	decision.Proof = ge.proofGen.GenerateProofWithTime(
-		decision.Permitted,
-		evaluatedIDs,
-		decision.Signals,
-		ge.versions,
-		logicalTime,
-	)
+// exhaustedEvaluation is the fail-closed evaluation of reg once meter is exhausted
+func exhaustedEvaluation(reg *registration, meter *costMeter) *primitiveEvaluation {
+	ev := newPrimitiveEvaluation(reg, meter.failure().withPrimitive(reg.id, reg.version).result())
+	ev.exhausted = true
+	return ev
+}
 
-	return decision
+// noPrimitivesReason explains a denial caused by no primitive applying to dctx
+func noPrimitivesReason(dctx *DeterministicContext) string {
+	if actionType, ok := contextActionType(dctx); ok {
+		return fmt.Sprintf("No primitives registered for action type '%s'", actionType)
+	}
+	return "No primitives registered for context"
+}
+
+// failureReason builds the human-readable failure reason for a failed primitive result
+func failureReason(id string, outcome Outcome, result map[string]interface{}) string {
+	prefix := fmt.Sprintf("Primitive '%s' failed", id)
+	if outcome == Indeterminate {
+		prefix = fmt.Sprintf("Primitive '%s' failed (indeterminate)", id)
+	}
+	if meta, ok := result["metadata"].(map[string]interface{}); ok {
+		if r, ok := meta["reason"].(string); ok {
+			return fmt.Sprintf("%s: %s", prefix, r)
+		}
+	}
+	return prefix
 }
 
 // PrimitiveCount returns number of registered primitives
 func (ge *GovernanceEngine) PrimitiveCount() int {
-	ge.mu.RLock()
-	defer ge.mu.RUnlock()
-	return len(ge.primitives)
+	return len(ge.snapshot().registrations)
 }
 
 // Clear removes all registered primitives
 func (ge *GovernanceEngine) Clear() {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
-	ge.primitives = []GovernancePrimitive{}
-	ge.primitiveIDs = []string{}
-	ge.versions = make(map[string]string)
-}
\ No newline at end of file
+	_ = ge.commit(nil)
+}
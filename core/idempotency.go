@@ -0,0 +1,199 @@
+/*
+Idempotent evaluation for GSAS.
+
+Deduplicates evaluations that carry a caller-supplied request ID, so a
+retried request receives the original decision and proof rather than a new
+decision in the audit trail.
+*/
+
+package core
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailureIdempotencyConflict means a request ID was reused with a different context
+const FailureIdempotencyConflict FailureKind = "idempotency_conflict"
+
+// WithIdempotencyWindow deduplicates evaluations carrying a request ID for window
+// after their decision, remembering at most maxEntries request IDs (zero or less
+// is unlimited). A window of zero or less disables deduplication.
+func WithIdempotencyWindow(window time.Duration, maxEntries int) EngineOption {
+	return func(ge *GovernanceEngine) {
+		if window <= 0 {
+			return
+		}
+		ge.idempotency = newIdempotencyStore(window, maxEntries, ge.idempotencyClock)
+	}
+}
+
+// WithIdempotencyClock sets the clock that measures the idempotency window, whose
+// readings are taken as nanoseconds. The default is WallClock.
+func WithIdempotencyClock(clock Clock) EngineOption {
+	return func(ge *GovernanceEngine) {
+		if clock == nil {
+			return
+		}
+		ge.idempotencyClock = clock
+		if ge.idempotency != nil {
+			ge.idempotency.clock = clock
+		}
+	}
+}
+
+// WithRequestID identifies one evaluation request for deduplication.
+// Every call in a batch shares its options, so it should not be passed to EvaluateBatch.
+func WithRequestID(id string) EvaluateOption {
+	return func(c *evaluationConfig) {
+		c.requestID = id
+	}
+}
+
+// forTenant scopes the request ID of one hierarchy evaluation to a tenant path
+func forTenant(path string) EvaluateOption {
+	return func(c *evaluationConfig) {
+		c.tenant = path
+	}
+}
+
+// idempotencyStore remembers the decision made for each request ID
+type idempotencyStore struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Oldest first
+	clock      Clock
+}
+
+// idempotencyEntry is the decision for one request ID, pending until done is closed
+type idempotencyEntry struct {
+	id          string
+	contextHash string
+	recordedAt  int64 // Clock reading
+	completed   bool
+	done        chan struct{}
+	decision    *GovernanceDecision
+}
+
+// newIdempotencyStore creates an empty idempotency store measuring time with
+// clock, or WallClock if clock is nil
+func newIdempotencyStore(window time.Duration, maxEntries int, clock Clock) *idempotencyStore {
+	if clock == nil {
+		clock = WallClock{}
+	}
+	return &idempotencyStore{
+		window:     window,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		clock:      clock,
+	}
+}
+
+// claim returns the entry for id, creating it if id is unknown or expired.
+// The caller owns a created entry and must complete it.
+func (s *idempotencyStore) claim(id, contextHash string) (*idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	// Pruning stops at a pending entry, so entries behind it are checked on lookup
+	for front := s.order.Front(); front != nil && s.expired(front.Value.(*idempotencyEntry), now); front = s.order.Front() {
+		s.remove(front)
+	}
+
+	if elem, ok := s.entries[id]; ok {
+		if entry := elem.Value.(*idempotencyEntry); !s.expired(entry, now) {
+			return entry, false
+		}
+		s.remove(elem)
+	}
+
+	entry := &idempotencyEntry{
+		id:          id,
+		contextHash: contextHash,
+		recordedAt:  now,
+		done:        make(chan struct{}),
+	}
+	s.entries[id] = s.order.PushBack(entry)
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		s.remove(s.order.Front())
+	}
+	return entry, true
+}
+
+// expired reports whether entry holds a decision older than the window at time now
+func (s *idempotencyStore) expired(entry *idempotencyEntry, now int64) bool {
+	return entry.completed && now-entry.recordedAt >= int64(s.window)
+}
+
+// complete records decision for a claimed entry and wakes its waiters.
+// Decisions affected by transient failures are not recorded, so a retry re-evaluates.
+func (s *idempotencyStore) complete(entry *idempotencyEntry, decision *GovernanceDecision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, tracked := s.entries[entry.id]
+	tracked = tracked && elem.Value.(*idempotencyEntry) == entry
+	if cacheable(decision) {
+		entry.decision = decision.clone()
+		entry.recordedAt = s.clock.Now()
+		entry.completed = true
+		if tracked {
+			s.order.MoveToBack(elem)
+		}
+	} else if tracked {
+		s.remove(elem)
+	}
+	close(entry.done)
+}
+
+// remove forgets elem. The caller must hold s.mu.
+func (s *idempotencyStore) remove(elem *list.Element) {
+	entry := s.order.Remove(elem).(*idempotencyEntry)
+	delete(s.entries, entry.id)
+}
+
+// evaluateIdempotent evaluates dctx at most once per request ID within the window.
+// A repeated request receives a copy of the original decision without notifying
+// interceptors; a request reusing an ID with a different context is denied.
+// Request IDs evaluated through a policy hierarchy are scoped to their tenant path.
+func (ge *GovernanceEngine) evaluateIdempotent(ctx context.Context, snap *registrySnapshot, dctx *DeterministicContext, cfg evaluationConfig, hooks hookChain) *GovernanceDecision {
+	contextHash, err := dctx.Hash()
+	if err != nil {
+		return ge.decide(ctx, snap, dctx, cfg, hooks)
+	}
+
+	key := cfg.requestID
+	if cfg.tenant != "" {
+		key = cfg.tenant + "\x00" + cfg.requestID
+	}
+	for {
+		entry, owner := ge.idempotency.claim(key, contextHash)
+		if owner {
+			decision := ge.decide(ctx, snap, dctx, cfg, hooks)
+			ge.idempotency.complete(entry, decision)
+			return decision
+		}
+
+		if entry.contextHash != contextHash {
+			return ge.deny(snap, cfg.clock, hooks, fmt.Sprintf(
+				"%s: request ID %q was already used with a different context", FailureIdempotencyConflict, cfg.requestID))
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return ge.deny(snap, cfg.clock, hooks, contextFailure(ctx, ctx.Err(), 0).reason)
+		}
+		if entry.decision != nil {
+			return entry.decision.clone()
+		}
+		// The original evaluation was not recorded; claim the ID again
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Obligations and advice attached to GSAS governance decisions.
+
+An obligation is a condition on a permit ("permit, but log to the regulator
+feed"); the caller must acknowledge fulfilling every obligation before the
+permit is honoured. Advice is informational and never enforced.
+*/
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Obligation is a condition the caller must fulfil for a permit to be honoured
+type Obligation struct {
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Source     string                 `json:"source,omitempty"` // Primitive that attached it
+}
+
+// Advice is an informational hint attached to a decision
+type Advice struct {
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Source     string                 `json:"source,omitempty"` // Primitive that attached it
+}
+
+// UnfulfilledObligationsError is returned when a permit is enforced without
+// acknowledging every obligation attached to it
+type UnfulfilledObligationsError struct {
+	Missing []string
+}
+
+func (e *UnfulfilledObligationsError) Error() string {
+	return fmt.Sprintf("unfulfilled obligations: %s", strings.Join(e.Missing, ", "))
+}
+
+// EnforceObligations fails closed unless the decision is a permit and every
+// obligation attached to it is in acknowledged
+func (d *GovernanceDecision) EnforceObligations(acknowledged ...string) error {
+	if d == nil {
+		return errors.New("no governance decision")
+	}
+	if !d.Permitted {
+		return errors.New("governance decision does not permit execution")
+	}
+
+	acked := make(map[string]bool, len(acknowledged))
+	for _, id := range acknowledged {
+		acked[id] = true
+	}
+
+	seen := make(map[string]bool)
+	missing := []string{}
+	for _, o := range d.Obligations {
+		if !acked[o.ID] && !seen[o.ID] {
+			seen[o.ID] = true
+			missing = append(missing, o.ID)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &UnfulfilledObligationsError{Missing: missing}
+	}
+	return nil
+}
+
+// obligationEntry is the common shape of a parsed obligation or advice entry
+type obligationEntry struct {
+	id         string
+	attributes map[string]interface{}
+	source     string
+}
+
+// parseEntries parses the obligations or advice under key in a primitive result.
+// Entries may be Obligation/Advice values, maps with an "id" key, or bare IDs.
+func parseEntries(result map[string]interface{}, key, source string) ([]obligationEntry, error) {
+	raw, ok := result[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	var items []interface{}
+	switch v := raw.(type) {
+	case []Obligation:
+		for _, o := range v {
+			items = append(items, o)
+		}
+	case []Advice:
+		for _, a := range v {
+			items = append(items, a)
+		}
+	case []string:
+		for _, s := range v {
+			items = append(items, s)
+		}
+	case []interface{}:
+		items = v
+	default:
+		return nil, fmt.Errorf("'%s' must be a list, got %T", key, raw)
+	}
+
+	entries := make([]obligationEntry, 0, len(items))
+	for i, item := range items {
+		entry := obligationEntry{source: source}
+		switch v := item.(type) {
+		case Obligation:
+			entry.id, entry.attributes = v.ID, v.Attributes
+			if v.Source != "" {
+				entry.source = v.Source
+			}
+		case Advice:
+			entry.id, entry.attributes = v.ID, v.Attributes
+			if v.Source != "" {
+				entry.source = v.Source
+			}
+		case string:
+			entry.id = v
+		case map[string]interface{}:
+			entry.id, _ = v["id"].(string)
+			if attrs, ok := v["attributes"].(map[string]interface{}); ok {
+				entry.attributes = attrs
+			}
+		default:
+			return nil, fmt.Errorf("'%s'[%d] has unsupported type %T", key, i, item)
+		}
+		if entry.id == "" {
+			return nil, fmt.Errorf("'%s'[%d] has no id", key, i)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// resultObligations parses the obligations and advice of a primitive result
+func resultObligations(result map[string]interface{}, source string) ([]Obligation, []Advice, error) {
+	oEntries, err := parseEntries(result, "obligations", source)
+	if err != nil {
+		return nil, nil, err
+	}
+	aEntries, err := parseEntries(result, "advice", source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obligations := make([]Obligation, len(oEntries))
+	for i, e := range oEntries {
+		obligations[i] = Obligation{ID: e.id, Attributes: copyAttributes(e.attributes), Source: e.source}
+	}
+	advice := make([]Advice, len(aEntries))
+	for i, e := range aEntries {
+		advice[i] = Advice{ID: e.id, Attributes: copyAttributes(e.attributes), Source: e.source}
+	}
+	return obligations, advice, nil
+}
+
+// copyAttributes returns a deep copy of obligation or advice attributes
+func copyAttributes(attrs map[string]interface{}) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	return deepCopyValue(attrs).(map[string]interface{})
+}
+
+// cloneObligations returns a deep copy of obligations
+func cloneObligations(obligations []Obligation) []Obligation {
+	copied := slices.Clone(obligations)
+	for i, o := range copied {
+		copied[i].Attributes = copyAttributes(o.Attributes)
+	}
+	return copied
+}
+
+// cloneAdvice returns a deep copy of advice
+func cloneAdvice(advice []Advice) []Advice {
+	copied := slices.Clone(advice)
+	for i, a := range copied {
+		copied[i].Attributes = copyAttributes(a.Attributes)
+	}
+	return copied
+}
+
+// malformedFailure converts an unparseable primitive result into a failure
+func malformedFailure(err error) *evaluationFailure {
+	return &evaluationFailure{
+		kind:   FailureMalformedResult,
+		reason: fmt.Sprintf("malformed result: %v", err),
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Deterministic parallel evaluation for GSAS.
+
+Primitives that declare no dependencies may be evaluated concurrently. Their
+results are assembled in canonical evaluation order, so signals, failure
+reasons and commitments are identical to a serial run.
+*/
+
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WithParallelism evaluates primitives that declare no dependencies on up to n
+// goroutines. Values below two keep evaluation serial.
+//
+// Results are assembled in canonical order, so the decision and proof match a
+// serial run as long as no primitive times out. Interceptor primitive hooks run
+// during assembly, in canonical order.
+func WithParallelism(n int) EngineOption {
+	return func(ge *GovernanceEngine) {
+		ge.parallelism = n
+	}
+}
+
+// parallelFor calls fn for every index in [0, n) on up to workers goroutines
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// prefetch concurrently evaluates the registrations that declare no dependencies
+// against the unmetered dctx, returning nil when parallel evaluation is disabled.
+// In FailFast mode, a gating primitive after the first gating denial found so far
+// is not started, as its result would be discarded. When the decision is metered,
+// composites, which charge the meter as their children run, are left to serial
+// evaluation; the cost of the other primitives is charged as their results are
+// consumed, so the point at which a budget runs out does not depend on scheduling.
+func (ge *GovernanceEngine) prefetch(ctx context.Context, regs []*registration, dctx *DeterministicContext, mode EvaluationMode, metered bool) map[*registration]*primitiveEvaluation {
+	if ge.parallelism < 2 {
+		return nil
+	}
+
+	independent := make([]*registration, 0, len(regs))
+	for _, reg := range regs {
+		if len(reg.dependencies) > 0 {
+			continue
+		}
+		if _, composite := reg.primitive.(compositePrimitive); composite && metered && !reg.shadow {
+			continue
+		}
+		independent = append(independent, reg)
+	}
+	if len(independent) < 2 {
+		return nil
+	}
+
+	var firstDenial atomic.Int64
+	firstDenial.Store(int64(len(independent)))
+	evaluations := make([]*primitiveEvaluation, len(independent))
+	parallelFor(len(independent), ge.parallelism, func(i int) {
+		reg := independent[i]
+		failFast := mode == FailFast && !reg.shadow
+		if failFast && int64(i) > firstDenial.Load() {
+			return
+		}
+		ev := ge.evaluatePrimitive(ctx, reg, dctx)
+		evaluations[i] = ev
+		for denial := firstDenial.Load(); failFast && !ev.outcome.Allows() && int64(i) < denial; denial = firstDenial.Load() {
+			if firstDenial.CompareAndSwap(denial, int64(i)) {
+				break
+			}
+		}
+	})
+
+	prefetched := make(map[*registration]*primitiveEvaluation, len(independent))
+	for i, reg := range independent {
+		if evaluations[i] != nil {
+			prefetched[reg] = evaluations[i]
+		}
+	}
+	return prefetched
+}
+
+// takePrefetched returns the prefetched evaluation of reg, if any, charging a
+// gating primitive's cost to the run's meter as its serial evaluation would have
+func (r *evaluationRun) takePrefetched(prefetched map[*registration]*primitiveEvaluation, reg *registration) *primitiveEvaluation {
+	ev := prefetched[reg]
+	if ev == nil || reg.shadow || r.meter == nil {
+		return ev
+	}
+	if !r.meter.charge(primitiveCost(reg.primitive)) {
+		return exhaustedEvaluation(reg, r.meter)
+	}
+	return ev
+}
@@ -0,0 +1,672 @@
+/*
+Persistent immutable data structures for GSAS contexts.
+
+A persistent map (a hash array mapped trie) and a persistent vector (a
+32-way trie) never change once built; updates return a new version that
+shares every untouched node with the old one. Contexts can therefore be
+frozen once and derived or shared without copying or locking.
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+const (
+	trieBits  = 5
+	trieWidth = 1 << trieBits
+	trieMask  = trieWidth - 1
+)
+
+// pmap is a persistent map from strings to frozen values
+type pmap struct {
+	root *hamtNode
+	size int
+}
+
+// hamtNode is a bitmap-compressed trie node; entries hold only the occupied slots
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// hamtEntry is a leaf, a sub-trie, or a bucket of keys whose hashes fully collide
+type hamtEntry struct {
+	hash   uint32
+	key    string
+	value  interface{}
+	child  *hamtNode
+	bucket []hamtPair
+}
+
+// hamtPair is one key/value pair in a collision bucket
+type hamtPair struct {
+	key   string
+	value interface{}
+}
+
+// emptyPmap is the shared empty map
+var emptyPmap = &pmap{root: &hamtNode{}}
+
+// hashKey hashes a map key
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// slot returns the bit and compressed position of hash at shift in n
+func (n *hamtNode) slot(hash uint32, shift uint) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & trieMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// Len returns the number of keys
+func (m *pmap) Len() int {
+	return m.size
+}
+
+// get returns the value for key
+func (m *pmap) get(key string) (interface{}, bool) {
+	hash := hashKey(key)
+	n := m.root
+	for shift := uint(0); ; shift += trieBits {
+		bit, pos := n.slot(hash, shift)
+		if n.bitmap&bit == 0 {
+			return nil, false
+		}
+		e := &n.entries[pos]
+		switch {
+		case e.child != nil:
+			n = e.child
+		case e.bucket != nil:
+			for _, p := range e.bucket {
+				if p.key == key {
+					return p.value, true
+				}
+			}
+			return nil, false
+		default:
+			if e.key == key {
+				return e.value, true
+			}
+			return nil, false
+		}
+	}
+}
+
+// set returns a map with key bound to value, sharing all untouched nodes with m
+func (m *pmap) set(key string, value interface{}) *pmap {
+	root, added := m.root.set(hamtEntry{hash: hashKey(key), key: key, value: value}, 0)
+	size := m.size
+	if added {
+		size++
+	}
+	return &pmap{root: root, size: size}
+}
+
+// set returns a copy of n with leaf inserted, and whether the key was new
+func (n *hamtNode) set(leaf hamtEntry, shift uint) (*hamtNode, bool) {
+	bit, pos := n.slot(leaf.hash, shift)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry, len(n.entries)+1)
+		copy(entries, n.entries[:pos])
+		entries[pos] = leaf
+		copy(entries[pos+1:], n.entries[pos:])
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+
+	e := n.entries[pos]
+	added := false
+	switch {
+	case e.child != nil:
+		child, childAdded := e.child.set(leaf, shift+trieBits)
+		e = hamtEntry{child: child}
+		added = childAdded
+	case e.bucket != nil && e.hash == leaf.hash:
+		bucket := make([]hamtPair, 0, len(e.bucket)+1)
+		added = true
+		for _, p := range e.bucket {
+			if p.key == leaf.key {
+				p.value = leaf.value
+				added = false
+			}
+			bucket = append(bucket, p)
+		}
+		if added {
+			bucket = append(bucket, hamtPair{key: leaf.key, value: leaf.value})
+		}
+		e = hamtEntry{hash: e.hash, bucket: bucket}
+	case e.bucket == nil && e.key == leaf.key:
+		e = leaf
+	case e.bucket == nil && e.hash == leaf.hash:
+		e = hamtEntry{hash: e.hash, bucket: []hamtPair{{e.key, e.value}, {leaf.key, leaf.value}}}
+		added = true
+	default:
+		e = hamtEntry{child: mergeEntries(e, leaf, shift+trieBits)}
+		added = true
+	}
+
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[pos] = e
+	return &hamtNode{bitmap: n.bitmap, entries: entries}, added
+}
+
+// insert adds leaf to n in place, reporting whether the key was new.
+// It must only be used on nodes that have not been shared yet.
+func (n *hamtNode) insert(leaf hamtEntry, shift uint) bool {
+	bit, pos := n.slot(leaf.hash, shift)
+	if n.bitmap&bit == 0 {
+		n.entries = append(n.entries, hamtEntry{})
+		copy(n.entries[pos+1:], n.entries[pos:])
+		n.entries[pos] = leaf
+		n.bitmap |= bit
+		return true
+	}
+
+	e := &n.entries[pos]
+	switch {
+	case e.child != nil:
+		return e.child.insert(leaf, shift+trieBits)
+	case e.bucket != nil && e.hash == leaf.hash:
+		for i := range e.bucket {
+			if e.bucket[i].key == leaf.key {
+				e.bucket[i].value = leaf.value
+				return false
+			}
+		}
+		e.bucket = append(e.bucket, hamtPair{key: leaf.key, value: leaf.value})
+	case e.bucket == nil && e.key == leaf.key:
+		*e = leaf
+		return false
+	case e.bucket == nil && e.hash == leaf.hash:
+		*e = hamtEntry{hash: e.hash, bucket: []hamtPair{{e.key, e.value}, {leaf.key, leaf.value}}}
+	default:
+		*e = hamtEntry{child: mergeEntries(*e, leaf, shift+trieBits)}
+	}
+	return true
+}
+
+// mergeEntries builds the sub-trie holding two entries with different hashes
+func mergeEntries(a, b hamtEntry, shift uint) *hamtNode {
+	ia, ib := (a.hash>>shift)&trieMask, (b.hash>>shift)&trieMask
+	if ia == ib {
+		return &hamtNode{bitmap: 1 << ia, entries: []hamtEntry{{child: mergeEntries(a, b, shift+trieBits)}}}
+	}
+	if ia > ib {
+		a, b = b, a
+		ia, ib = ib, ia
+	}
+	return &hamtNode{bitmap: 1<<ia | 1<<ib, entries: []hamtEntry{a, b}}
+}
+
+// each calls fn for every key and value, in no particular order
+func (n *hamtNode) each(fn func(key string, value interface{})) {
+	for _, e := range n.entries {
+		switch {
+		case e.child != nil:
+			e.child.each(fn)
+		case e.bucket != nil:
+			for _, p := range e.bucket {
+				fn(p.key, p.value)
+			}
+		default:
+			fn(e.key, e.value)
+		}
+	}
+}
+
+// keys returns the keys of m in sorted order
+func (m *pmap) keys() []string {
+	keys := make([]string, 0, m.size)
+	m.root.each(func(key string, _ interface{}) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// MarshalJSON encodes the map as a JSON object with sorted keys
+func (m *pmap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := m.get(key)
+		v, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// pvector is a persistent vector of frozen values
+type pvector struct {
+	root  *vectorNode
+	shift uint // Depth of the trie times trieBits
+	size  int
+}
+
+// vectorNode is a branch (children) or a leaf (values) of the vector trie
+type vectorNode struct {
+	children []*vectorNode
+	values   []interface{}
+}
+
+// newPvector builds a vector holding values
+func newPvector(values []interface{}) *pvector {
+	nodes := make([]*vectorNode, 0, (len(values)+trieMask)/trieWidth)
+	for start := 0; start < len(values); start += trieWidth {
+		end := min(start+trieWidth, len(values))
+		nodes = append(nodes, &vectorNode{values: append([]interface{}{}, values[start:end]...)})
+	}
+	if len(nodes) == 0 {
+		return &pvector{root: &vectorNode{}}
+	}
+
+	shift := uint(0)
+	for len(nodes) > 1 {
+		parents := make([]*vectorNode, 0, (len(nodes)+trieMask)/trieWidth)
+		for start := 0; start < len(nodes); start += trieWidth {
+			end := min(start+trieWidth, len(nodes))
+			parents = append(parents, &vectorNode{children: append([]*vectorNode{}, nodes[start:end]...)})
+		}
+		nodes = parents
+		shift += trieBits
+	}
+	return &pvector{root: nodes[0], shift: shift, size: len(values)}
+}
+
+// Len returns the number of elements
+func (v *pvector) Len() int {
+	return v.size
+}
+
+// get returns the element at index i
+func (v *pvector) get(i int) (interface{}, bool) {
+	if i < 0 || i >= v.size {
+		return nil, false
+	}
+	n := v.root
+	for shift := v.shift; shift > 0; shift -= trieBits {
+		n = n.children[(i>>shift)&trieMask]
+	}
+	return n.values[i&trieMask], true
+}
+
+// appended returns a vector with value added at the end, sharing all untouched nodes with v
+func (v *pvector) appended(value interface{}) *pvector {
+	// The root is full: grow the trie by one level
+	if v.size > 0 && v.size == 1<<(v.shift+trieBits) {
+		root := &vectorNode{children: []*vectorNode{v.root, newVectorPath(v.shift, value)}}
+		return &pvector{root: root, shift: v.shift + trieBits, size: v.size + 1}
+	}
+	return &pvector{root: v.root.appended(v.size, v.shift, value), shift: v.shift, size: v.size + 1}
+}
+
+// appended returns a copy of n with value stored at index i
+func (n *vectorNode) appended(i int, shift uint, value interface{}) *vectorNode {
+	if shift == 0 {
+		return &vectorNode{values: append(append(make([]interface{}, 0, len(n.values)+1), n.values...), value)}
+	}
+	slot := (i >> shift) & trieMask
+	children := append(make([]*vectorNode, 0, slot+1), n.children...)
+	if slot < len(children) {
+		children[slot] = children[slot].appended(i, shift-trieBits, value)
+	} else {
+		children = append(children, newVectorPath(shift-trieBits, value))
+	}
+	return &vectorNode{children: children}
+}
+
+// newVectorPath builds a path of nodes down to a leaf holding value
+func newVectorPath(shift uint, value interface{}) *vectorNode {
+	if shift == 0 {
+		return &vectorNode{values: []interface{}{value}}
+	}
+	return &vectorNode{children: []*vectorNode{newVectorPath(shift-trieBits, value)}}
+}
+
+// each calls fn for every element in order
+func (v *pvector) each(fn func(i int, value interface{})) {
+	i := 0
+	var walk func(n *vectorNode)
+	walk = func(n *vectorNode) {
+		for _, c := range n.children {
+			walk(c)
+		}
+		for _, value := range n.values {
+			fn(i, value)
+			i++
+		}
+	}
+	walk(v.root)
+}
+
+// MarshalJSON encodes the vector as a JSON array
+func (v *pvector) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	var err error
+	v.each(func(i int, value interface{}) {
+		if err != nil {
+			return
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		var data []byte
+		if data, err = json.Marshal(value); err == nil {
+			buf.Write(data)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// freezer converts values into their immutable form, enforcing the limits of
+// the context being built. keys and size accumulate over every value it freezes.
+type freezer struct {
+	limits contextLimits
+	keys   int
+	size   int
+}
+
+// valuePath is the path of a value being frozen, from its innermost step out.
+// It is rendered only when a value is rejected, so freezing a large context
+// does not format a path for every value it holds. A nil path is the root.
+type valuePath struct {
+	parent  *valuePath
+	key     string
+	index   int
+	element bool // A list index rather than a map key
+}
+
+// String renders the path in dotted form, e.g. "orders[0].amount"
+func (p *valuePath) String() string {
+	switch {
+	case p == nil:
+		return ""
+	case p.element:
+		return p.parent.String() + "[" + strconv.Itoa(p.index) + "]"
+	}
+	return joinPath(p.parent.String(), p.key)
+}
+
+// reject returns a validation error for the value at path
+func (f *freezer) reject(path *valuePath, format string, args ...interface{}) error {
+	return &ContextValidationError{Path: path.String(), Reason: fmt.Sprintf(format, args...)}
+}
+
+// grow adds n bytes to the running size
+func (f *freezer) grow(path *valuePath, n int) error {
+	f.size += n
+	if f.limits.maxBytes > 0 && f.size > f.limits.maxBytes {
+		return f.reject(path, "context exceeds the maximum size of %d bytes", f.limits.maxBytes)
+	}
+	return nil
+}
+
+// freeze converts the value at path, nested depth containers deep, into its
+// immutable form, keeping its type. Signed and unsigned integers widen to int64
+// and uint64, *big.Int, Decimal and []byte are copied, and maps and slices become
+// persistent maps and vectors. Typed slices, arrays, string-keyed maps, pointers
+// and named basic types are walked by reflection, so their elements keep their
+// Go types too. Other values are normalised through their JSON encoding, whose
+// integer literals become integers and whose other numbers become exact decimals.
+func (f *freezer) freeze(v interface{}, path *valuePath, depth int) (interface{}, error) {
+	if depth > f.limits.maxDepth {
+		return nil, f.reject(path, "value exceeds the maximum nesting depth of %d", f.limits.maxDepth)
+	}
+	switch x := v.(type) {
+	case nil, bool:
+		return x, f.grow(path, 1)
+	case string:
+		return x, f.grow(path, len(x))
+	case int64, uint64:
+		return x, f.grow(path, 8)
+	case frozenBytes:
+		return x, f.grow(path, len(x))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, f.reject(path, "non-finite number %v", x)
+		}
+		return x, f.grow(path, 8)
+	case float32:
+		return f.freeze(float64(x), path, depth)
+	case int:
+		return f.freeze(int64(x), path, depth)
+	case int8:
+		return f.freeze(int64(x), path, depth)
+	case int16:
+		return f.freeze(int64(x), path, depth)
+	case int32:
+		return f.freeze(int64(x), path, depth)
+	case uint:
+		return f.freeze(uint64(x), path, depth)
+	case uint8:
+		return f.freeze(uint64(x), path, depth)
+	case uint16:
+		return f.freeze(uint64(x), path, depth)
+	case uint32:
+		return f.freeze(uint64(x), path, depth)
+	case *big.Int:
+		if x == nil {
+			return f.freeze(nil, path, depth)
+		}
+		return new(big.Int).Set(x), f.grow(path, len(x.Bytes()))
+	case Decimal:
+		d := NewDecimalFromBigInt(x.unscaled, x.scale)
+		return d, f.grow(path, len(d.unscaled.Bytes())+4)
+	case []byte:
+		return f.freeze(frozenBytes(x), path, depth)
+	case json.Number:
+		n, err := freezeNumber(x)
+		if err != nil {
+			return nil, f.reject(path, "%v", err)
+		}
+		return f.freeze(n, path, depth)
+	case map[string]interface{}:
+		return f.freezeMap(x, path, depth)
+	case []interface{}:
+		values := make([]interface{}, len(x))
+		for i, item := range x {
+			frozen, err := f.freeze(item, &valuePath{parent: path, index: i, element: true}, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = frozen
+		}
+		return newPvector(values), nil
+	default:
+		if frozen, ok, err := f.freezeReflect(x, path, depth); ok {
+			return frozen, err
+		}
+		data, err := json.Marshal(x)
+		if err != nil {
+			return nil, f.reject(path, "unsupported value of type %T", x)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err != nil {
+			return nil, f.reject(path, "unsupported value of type %T", x)
+		}
+		return f.freeze(decoded, path, depth)
+	}
+}
+
+// freezeReflect freezes a value that has no case in freeze by its reflected kind,
+// reporting false for values left to their JSON encoding: structs, maps without
+// string keys, and any value with its own JSON or text encoding
+func (f *freezer) freezeReflect(v interface{}, path *valuePath, depth int) (interface{}, bool, error) {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return nil, false, nil
+	}
+	freeze := func(value interface{}) (interface{}, bool, error) {
+		frozen, err := f.freeze(value, path, depth)
+		return frozen, true, err
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return freeze(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return freeze(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return freeze(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return freeze(rv.Float())
+	case reflect.String:
+		return freeze(rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return freeze(nil)
+		}
+		return freeze(rv.Elem().Interface())
+	case reflect.Slice:
+		if rv.IsNil() {
+			return freeze(nil)
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return freeze(frozenBytes(rv.Bytes()))
+		}
+		fallthrough
+	case reflect.Array:
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			frozen, err := f.freeze(rv.Index(i).Interface(), &valuePath{parent: path, index: i, element: true}, depth+1)
+			if err != nil {
+				return nil, true, err
+			}
+			values[i] = frozen
+		}
+		return newPvector(values), true, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false, nil
+		}
+		if rv.IsNil() {
+			return freeze(nil)
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		frozen, err := f.freezeMap(m, path, depth)
+		return frozen, true, err
+	}
+	return nil, false, nil
+}
+
+// freezeMap converts the map at path into a persistent map of frozen values.
+// Keys are frozen in sorted order, so the path reported for a rejected
+// value does not depend on map iteration order.
+func (f *freezer) freezeMap(m map[string]interface{}, path *valuePath, depth int) (*pmap, error) {
+	// The trie is private until returned, so it is built in place
+	frozen := &pmap{root: &hamtNode{}}
+	for _, key := range sortedKeys(m) {
+		keyPath := &valuePath{parent: path, key: key}
+		if err := f.addKey(keyPath, key); err != nil {
+			return nil, err
+		}
+		value, err := f.freeze(m[key], keyPath, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if frozen.root.insert(hamtEntry{hash: hashKey(key), key: key, value: value}, 0) {
+			frozen.size++
+		}
+	}
+	return frozen, nil
+}
+
+// addKey counts the map key at path
+func (f *freezer) addKey(path *valuePath, key string) error {
+	f.keys++
+	if f.limits.maxKeys > 0 && f.keys > f.limits.maxKeys {
+		return f.reject(path, "context exceeds the maximum of %d keys", f.limits.maxKeys)
+	}
+	return f.grow(path, len(key))
+}
+
+// joinPath appends a map key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// freezeNumber converts a JSON number literal into the narrowest exact kind:
+// int64, then uint64, then *big.Int for integers, and Decimal otherwise
+func freezeNumber(n json.Number) (interface{}, error) {
+	s := n.String()
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return u, nil
+	}
+	if b, ok := new(big.Int).SetString(s, 10); ok {
+		return b, nil
+	}
+	return ParseDecimal(s)
+}
+
+// thaw converts a frozen value into a fresh mutable copy
+func thaw(v interface{}) interface{} {
+	switch x := v.(type) {
+	case *pmap:
+		return thawMap(x)
+	case *pvector:
+		values := make([]interface{}, 0, x.size)
+		x.each(func(_ int, value interface{}) {
+			values = append(values, thaw(value))
+		})
+		return values
+	case *big.Int:
+		return new(big.Int).Set(x)
+	case frozenBytes:
+		return []byte(x)
+	default:
+		return x
+	}
+}
+
+// thawMap converts a persistent map into a fresh mutable map
+func thawMap(m *pmap) map[string]interface{} {
+	result := make(map[string]interface{}, m.size)
+	m.root.each(func(key string, value interface{}) {
+		result[key] = thaw(value)
+	})
+	return result
+}
@@ -0,0 +1,296 @@
+/*
+Hierarchical policy registries for GSAS.
+
+Primitives are registered at the organisation, team or agent level of a
+tenant path such as "acme/payments/agent-7". Every tenant inherits the
+primitives of its ancestors; a child level may add primitives but can never
+remove, replace or weaken those it inherits.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Hierarchy levels, from the root of a tenant path
+const (
+	LevelOrg   = "org"
+	LevelTeam  = "team"
+	LevelAgent = "agent"
+)
+
+// policyLevels names the levels of a tenant path by depth
+var policyLevels = []string{LevelOrg, LevelTeam, LevelAgent}
+
+// PolicyLevel identifies the hierarchy level that registered a primitive
+type PolicyLevel struct {
+	Level string `json:"level"` // LevelOrg, LevelTeam or LevelAgent
+	Scope string `json:"scope"` // Tenant path of the level, e.g. "acme/payments"
+}
+
+// policyNode holds the primitives registered at one level of the hierarchy
+type policyNode struct {
+	level         PolicyLevel
+	registrations []*registration // Replaced, never mutated
+	children      map[string]*policyNode
+}
+
+// PolicyHierarchy is an org -> team -> agent tree of policy registries
+type PolicyHierarchy struct {
+	engine   *GovernanceEngine // Evaluation settings and interceptors; its own registry is unused
+	mu       sync.Mutex
+	root     *policyNode
+	epoch    uint64
+	resolved map[string]*registrySnapshot // Effective registry by tenant path
+}
+
+// NewPolicyHierarchy creates an empty hierarchy for org, evaluating with opts
+func NewPolicyHierarchy(org string, opts ...EngineOption) (*PolicyHierarchy, error) {
+	if org == "" || strings.Contains(org, "/") {
+		return nil, fmt.Errorf("invalid organisation name '%s'", org)
+	}
+	return &PolicyHierarchy{
+		engine:   NewGovernanceEngine(opts...),
+		root:     &policyNode{level: PolicyLevel{Level: LevelOrg, Scope: org}},
+		resolved: make(map[string]*registrySnapshot),
+	}, nil
+}
+
+// AddInterceptor appends an interceptor to the hierarchy's hook chain
+func (h *PolicyHierarchy) AddInterceptor(i EvaluationInterceptor) error {
+	return h.engine.AddInterceptor(i)
+}
+
+// splitPath validates a tenant path and returns its segments
+func (h *PolicyHierarchy) splitPath(path string) ([]string, error) {
+	segments := strings.Split(path, "/")
+	if len(segments) > len(policyLevels) {
+		return nil, fmt.Errorf("tenant path '%s' is deeper than org/team/agent", path)
+	}
+	for _, s := range segments {
+		if s == "" {
+			return nil, fmt.Errorf("tenant path '%s' has an empty segment", path)
+		}
+	}
+	if segments[0] != h.root.level.Scope {
+		return nil, fmt.Errorf("tenant path '%s' is not in organisation '%s'", path, h.root.level.Scope)
+	}
+	return segments, nil
+}
+
+// lineage returns the existing nodes from the root along path.
+// The caller must hold h.mu.
+func (h *PolicyHierarchy) lineage(segments []string) []*policyNode {
+	nodes := []*policyNode{h.root}
+	node := h.root
+	for _, s := range segments[1:] {
+		child, ok := node.children[s]
+		if !ok {
+			break
+		}
+		nodes = append(nodes, child)
+		node = child
+	}
+	return nodes
+}
+
+// node returns the node for path, creating missing levels.
+// The caller must hold h.mu.
+func (h *PolicyHierarchy) node(segments []string) *policyNode {
+	node := h.root
+	for depth, s := range segments[1:] {
+		child, ok := node.children[s]
+		if !ok {
+			child = &policyNode{level: PolicyLevel{
+				Level: policyLevels[depth+1],
+				Scope: strings.Join(segments[:depth+2], "/"),
+			}}
+			if node.children == nil {
+				node.children = make(map[string]*policyNode)
+			}
+			node.children[s] = child
+		}
+		node = child
+	}
+	return node
+}
+
+// effective concatenates the registrations of nodes, from the root down
+func effective(nodes []*policyNode) []*registration {
+	registrations := make([]*registration, 0)
+	for _, n := range nodes {
+		registrations = append(registrations, n.registrations...)
+	}
+	return registrations
+}
+
+// validateSubtree checks that every tenant at or below node still has a
+// consistent effective registry if node's lineage holds inherited.
+// The caller must hold h.mu.
+func validateSubtree(node *policyNode, inherited []*registration) error {
+	registrations := append(append([]*registration{}, inherited...), node.registrations...)
+	if _, err := newRegistrySnapshot(registrations, 0); err != nil {
+		return fmt.Errorf("%s '%s': %w", node.level.Level, node.level.Scope, err)
+	}
+	for _, child := range node.children {
+		if err := validateSubtree(child, registrations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findID returns the level that registered id in node's subtree, if any
+func findID(node *policyNode, id string) (PolicyLevel, bool) {
+	for _, reg := range node.registrations {
+		if reg.id == id {
+			return node.level, true
+		}
+	}
+	for _, child := range node.children {
+		if level, ok := findID(child, id); ok {
+			return level, true
+		}
+	}
+	return PolicyLevel{}, false
+}
+
+// RegisterPrimitive registers a primitive at the level of path. The ID must be
+// unique among the primitives the level inherits and those registered below it.
+func (h *PolicyHierarchy) RegisterPrimitive(path, id string, p GovernancePrimitive, opts ...RegisterOption) error {
+	segments, err := h.splitPath(path)
+	if err != nil {
+		return err
+	}
+	reg, err := newRegistration(id, p, opts)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ancestors := h.lineage(segments)
+	if len(ancestors) == len(segments) {
+		// The level already exists: the ID must not be used by it or below it
+		if level, ok := findID(ancestors[len(ancestors)-1], id); ok {
+			return fmt.Errorf("primitive with ID '%s' already registered by %s '%s'", id, level.Level, level.Scope)
+		}
+		ancestors = ancestors[:len(ancestors)-1]
+	}
+	for _, n := range ancestors {
+		for _, existing := range n.registrations {
+			if existing.id == id {
+				return fmt.Errorf("primitive with ID '%s' already registered by %s '%s'", id, n.level.Level, n.level.Scope)
+			}
+		}
+	}
+
+	node := h.node(segments)
+	level := node.level
+	reg.level = &level
+	previous := node.registrations
+	node.registrations = append(append([]*registration{}, previous...), reg)
+	if err := validateSubtree(node, effective(ancestors)); err != nil {
+		node.registrations = previous
+		return err
+	}
+	h.changed()
+	return nil
+}
+
+// UnregisterPrimitive removes a primitive registered at the level of path.
+// Primitives inherited from an ancestor level cannot be removed.
+func (h *PolicyHierarchy) UnregisterPrimitive(path, id string) error {
+	segments, err := h.splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	nodes := h.lineage(segments)
+	for i, n := range nodes {
+		for j, reg := range n.registrations {
+			if reg.id != id {
+				continue
+			}
+			if i != len(segments)-1 {
+				return fmt.Errorf("primitive '%s' is inherited from %s '%s' and cannot be removed", id, n.level.Level, n.level.Scope)
+			}
+			previous := n.registrations
+			n.registrations = append(append([]*registration{}, previous[:j]...), previous[j+1:]...)
+			if err := validateSubtree(n, effective(nodes[:i])); err != nil {
+				n.registrations = previous
+				return err
+			}
+			h.changed()
+			return nil
+		}
+	}
+	return fmt.Errorf("primitive '%s' not registered", id)
+}
+
+// changed invalidates every resolved registry after a change.
+// The caller must hold h.mu.
+func (h *PolicyHierarchy) changed() {
+	h.epoch++
+	h.resolved = make(map[string]*registrySnapshot)
+	h.engine.InvalidateCache()
+}
+
+// resolve returns the effective registry of path: the primitives of every
+// level from the organisation down, in that order
+func (h *PolicyHierarchy) resolve(path string) (*registrySnapshot, error) {
+	segments, err := h.splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if snap, ok := h.resolved[path]; ok {
+		return snap, nil
+	}
+	snap, err := newRegistrySnapshot(effective(h.lineage(segments)), h.epoch)
+	if err != nil {
+		return nil, err
+	}
+	h.resolved[path] = snap
+	return snap, nil
+}
+
+// EffectivePrimitives returns the IDs of the primitives that apply to path, in registration order
+func (h *PolicyHierarchy) EffectivePrimitives(path string) ([]string, error) {
+	snap, err := h.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(snap.registrations))
+	for i, reg := range snap.registrations {
+		ids[i] = reg.id
+	}
+	return ids, nil
+}
+
+// Evaluate evaluates the effective primitives of path against dctx
+func (h *PolicyHierarchy) Evaluate(path string, dctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision {
+	return h.EvaluateContext(context.Background(), path, dctx, opts...)
+}
+
+// EvaluateContext evaluates the effective primitives of path against dctx, bounded by ctx.
+// An invalid tenant path fails closed.
+func (h *PolicyHierarchy) EvaluateContext(ctx context.Context, path string, dctx *DeterministicContext, opts ...EvaluateOption) *GovernanceDecision {
+	snap, err := h.resolve(path)
+	if err != nil {
+		empty, _ := newRegistrySnapshot(nil, h.epoch)
+		return h.engine.deny(empty, h.engine.config(opts).clock, h.engine.hooks(), err.Error())
+	}
+	return h.engine.evaluateSnapshot(ctx, snap, dctx, append(opts[:len(opts):len(opts)], forTenant(path))...)
+}
@@ -20,4 +20,89 @@ type GovernancePrimitive interface {
 type NamedPrimitive interface {
 	GovernancePrimitive
 	Name() string
-}
\ No newline at end of file
+}
+
+// DependentPrimitive is a primitive that consumes the signals of other primitives.
+// It is evaluated after its dependencies and can read their evidence through
+// DeterministicContext.Upstream.
+type DependentPrimitive interface {
+	GovernancePrimitive
+	Dependencies() []string
+}
+
+// Outcome is the four-valued result of evaluating a governance primitive
+type Outcome string
+
+const (
+	// Permit means the primitive allows the action
+	Permit Outcome = "permit"
+	// Deny means the primitive forbids the action
+	Deny Outcome = "deny"
+	// NotApplicable means the primitive has no opinion on the action
+	NotApplicable Outcome = "not_applicable"
+	// Indeterminate means the primitive could not reach a decision; it always fails closed
+	Indeterminate Outcome = "indeterminate"
+)
+
+// Allows reports whether the outcome lets execution proceed
+func (o Outcome) Allows() bool {
+	return o == Permit || o == NotApplicable
+}
+
+// ResultOutcome extracts the outcome of a primitive result.
+// An "outcome" key takes precedence over the legacy "valid" key; a missing,
+// unrecognised or contradictory answer is Indeterminate.
+func ResultOutcome(result map[string]interface{}) Outcome {
+	valid, hasValid := result["valid"].(bool)
+
+	var outcome Outcome
+	switch o := result["outcome"].(type) {
+	case Outcome:
+		outcome = o
+	case string:
+		outcome = Outcome(o)
+	case nil:
+		if !hasValid {
+			return Indeterminate
+		}
+		if valid {
+			return Permit
+		}
+		return Deny
+	default:
+		return Indeterminate
+	}
+
+	switch outcome {
+	case Permit, NotApplicable:
+		if hasValid && !valid {
+			return Indeterminate
+		}
+		return outcome
+	case Deny, Indeterminate:
+		return outcome
+	default:
+		return Indeterminate
+	}
+}
+
+// CombineOutcomes combines outcomes with deny-overrides semantics:
+// Deny beats Indeterminate, Indeterminate beats Permit, and the result is
+// NotApplicable only if every outcome is NotApplicable (or there are none)
+func CombineOutcomes(outcomes ...Outcome) Outcome {
+	combined := NotApplicable
+	for _, o := range outcomes {
+		switch o {
+		case Deny:
+			return Deny
+		case Permit:
+			if combined == NotApplicable {
+				combined = Permit
+			}
+		case NotApplicable:
+		default:
+			combined = Indeterminate
+		}
+	}
+	return combined
+}
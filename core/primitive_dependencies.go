@@ -0,0 +1,154 @@
+/*
+Primitive dependency graph for GSAS.
+
+Primitives may declare dependencies on other registered primitives. The
+engine keeps the graph acyclic, evaluates in topological order and exposes
+the evidence of upstream signals to downstream primitives read-only.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpstreamView is a read-only view of the signals a primitive depends on
+type UpstreamView struct {
+	signals map[string]map[string]interface{}
+}
+
+// IDs returns the IDs of the visible upstream primitives, sorted
+func (v *UpstreamView) IDs() []string {
+	if v == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(v.signals))
+	for id := range v.signals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Evidence returns a copy of the evidence produced by upstream primitive id
+func (v *UpstreamView) Evidence(id string) (interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	sig, ok := v.signals[id]
+	if !ok {
+		return nil, false
+	}
+	return deepCopyValue(sig["evidence"]), true
+}
+
+// Outcome returns the outcome of upstream primitive id
+func (v *UpstreamView) Outcome(id string) (Outcome, bool) {
+	if v == nil {
+		return "", false
+	}
+	sig, ok := v.signals[id]
+	if !ok {
+		return "", false
+	}
+	outcome, _ := sig["outcome"].(Outcome)
+	return outcome, true
+}
+
+// declaredDependencies returns the dependencies declared by p, if any
+func declaredDependencies(p GovernancePrimitive) []string {
+	dp, ok := p.(DependentPrimitive)
+	if !ok {
+		return nil
+	}
+	deps := dp.Dependencies()
+	if len(deps) == 0 {
+		return nil
+	}
+	return append([]string{}, deps...)
+}
+
+// topologicalOrder validates the dependency graph of registrations and returns
+// them in evaluation order. Ties are broken by registration order, so a graph
+// without dependencies evaluates in registration order. A gating primitive may
+// not depend on a shadow primitive, whose result must never affect the decision.
+func topologicalOrder(registrations []*registration) ([]*registration, error) {
+	index := make(map[string]int, len(registrations))
+	for i, reg := range registrations {
+		index[reg.id] = i
+	}
+
+	pending := make([]int, len(registrations))
+	dependents := make([][]int, len(registrations))
+	for i, reg := range registrations {
+		for _, dep := range reg.dependencies {
+			if dep == reg.id {
+				return nil, fmt.Errorf("primitive '%s' cannot depend on itself", reg.id)
+			}
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("primitive '%s' depends on unregistered primitive '%s'", reg.id, dep)
+			}
+			if !reg.shadow && registrations[j].shadow {
+				return nil, fmt.Errorf("gating primitive '%s' cannot depend on shadow primitive '%s'", reg.id, dep)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	order := make([]*registration, 0, len(registrations))
+	done := make([]bool, len(registrations))
+	for len(order) < len(registrations) {
+		next := -1
+		for i := range registrations {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			cycle := []string{}
+			for i, reg := range registrations {
+				if !done[i] {
+					cycle = append(cycle, reg.id)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among primitives: %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		order = append(order, registrations[next])
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+	return order, nil
+}
+
+// upstream builds the view of reg's dependencies, or a failure if any
+// dependency was not evaluated or did not allow the action
+func (r *evaluationRun) upstream(reg *registration) (*UpstreamView, *evaluationFailure) {
+	view := &UpstreamView{signals: make(map[string]map[string]interface{}, len(reg.dependencies))}
+	for _, dep := range reg.dependencies {
+		ev, ok := r.evaluated[dep]
+		if !ok {
+			return nil, dependencyFailure(dep, "was not evaluated")
+		}
+		if !ev.outcome.Allows() {
+			return nil, dependencyFailure(dep, fmt.Sprintf("did not permit (%s)", ev.outcome))
+		}
+		view.signals[dep] = ev.signal
+	}
+	return view, nil
+}
+
+// dependencyFailure reports an unsatisfied dependency
+func dependencyFailure(dep, why string) *evaluationFailure {
+	return &evaluationFailure{
+		kind:    FailureDependency,
+		reason:  fmt.Sprintf("dependency '%s' %s", dep, why),
+		details: map[string]interface{}{"dependency": dep},
+	}
+}
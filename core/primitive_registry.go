@@ -0,0 +1,99 @@
+/*
+Copy-on-write primitive registry for GSAS.
+
+Every registry change produces a new immutable snapshot. Evaluation works
+against the snapshot taken when it started, so registry changes never wait
+on slow primitives and each decision is tied to exactly one configuration.
+*/
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// registrySnapshot is an immutable view of the registered primitives
+type registrySnapshot struct {
+	registrations []*registration
+	order         []*registration // Topological evaluation order
+	versions      map[string]string
+	epoch         uint64
+	hash          string
+}
+
+// newRegistrySnapshot builds a snapshot from registrations at the given epoch.
+// It fails if the dependency graph is incomplete or cyclic.
+func newRegistrySnapshot(registrations []*registration, epoch uint64) (*registrySnapshot, error) {
+	order, err := topologicalOrder(registrations)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(registrations))
+	descriptors := make([]map[string]interface{}, len(registrations))
+	for i, reg := range registrations {
+		versions[reg.id] = reg.version
+		descriptors[i] = reg.descriptor()
+	}
+	data, err := json.Marshal(descriptors)
+	if err != nil {
+		data = []byte(err.Error())
+	}
+	return &registrySnapshot{
+		registrations: registrations,
+		order:         order,
+		versions:      versions,
+		epoch:         epoch,
+		hash:          fmt.Sprintf("%x", sha256.Sum256(data)),
+	}, nil
+}
+
+// descriptor describes everything about a registration that affects evaluation
+func (r *registration) descriptor() map[string]interface{} {
+	descriptor := map[string]interface{}{
+		"id":         r.id,
+		"version":    r.version,
+		"timeout_ns": r.timeout.Nanoseconds(),
+		"shadow":     r.shadow,
+		"scope":      r.scope.descriptor(),
+		"depends_on": r.dependencies,
+		"validity":   r.validity,
+	}
+	if r.level != nil {
+		descriptor["level"] = r.level
+	}
+	if len(r.schemas) > 0 {
+		schemas := make([]*Schema, len(r.schemas))
+		for i, s := range r.schemas {
+			schemas[i] = s.schema
+		}
+		descriptor["schemas"] = schemas
+	}
+	return descriptor
+}
+
+// indexOf returns the position of id in the snapshot, or -1
+func (s *registrySnapshot) indexOf(id string) int {
+	for i, reg := range s.registrations {
+		if reg.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// versionsCopy returns a copy of the primitive versions safe to hand out
+func (s *registrySnapshot) versionsCopy() map[string]string {
+	versions := make(map[string]string, len(s.versions))
+	for id, v := range s.versions {
+		versions[id] = v
+	}
+	return versions
+}
+
+// with returns a new snapshot at the next epoch holding registrations
+func (s *registrySnapshot) with(registrations []*registration) (*registrySnapshot, error) {
+	return newRegistrySnapshot(registrations, s.epoch+1)
+}
@@ -0,0 +1,156 @@
+/*
+Action-scoped primitive routing for GSAS.
+
+A scope restricts a registered primitive to the contexts it applies to, so
+rules never have to defensively no-op on unrelated actions.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Context keys the engine reads when routing primitives
+const (
+	// ActionTypeKey holds the action type of a context as a string
+	ActionTypeKey = "action_type"
+	// TagsKey holds the tags of a context as a list of strings
+	TagsKey = "tags"
+)
+
+// Scope restricts a primitive to matching contexts.
+// Every non-empty criterion must match; an empty Scope matches every context.
+type Scope struct {
+	// ActionTypes matches contexts whose action type is one of these
+	ActionTypes []string
+	// Tags matches contexts carrying at least one of these tags
+	Tags []string
+	// Predicate matches contexts for which it returns true
+	Predicate func(*DeterministicContext) bool
+	// PredicateID names and versions Predicate, e.g. "large_amount/v2", and is
+	// required with it. Functions cannot be hashed, so the registry hash records
+	// the predicate by this ID: change it whenever the predicate changes.
+	PredicateID string
+}
+
+// SkippedPrimitive records a primitive that was not evaluated because it was out of scope
+type SkippedPrimitive struct {
+	PrimitiveID string `json:"primitive_id"`
+	Reason      string `json:"reason"`
+}
+
+// WithScope restricts the registered primitive to contexts matching scope
+func WithScope(scope Scope) RegisterOption {
+	return func(r *registration) {
+		r.scope = scope
+	}
+}
+
+// contextActionType returns the action type of dctx, if any
+func contextActionType(dctx *DeterministicContext) (string, bool) {
+	if dctx == nil {
+		return "", false
+	}
+	actionType, ok := dctx.Get(ActionTypeKey, nil).(string)
+	return actionType, ok
+}
+
+// contextTags returns the tags of dctx
+func contextTags(dctx *DeterministicContext) []string {
+	if dctx == nil {
+		return nil
+	}
+	switch tags := dctx.Get(TagsKey, nil).(type) {
+	case []string:
+		return tags
+	case []interface{}:
+		result := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// applies reports whether the scope matches dctx, and why not when it does not.
+// A predicate that panics is treated as matching so the primitive still runs.
+func (s Scope) applies(dctx *DeterministicContext) (bool, string) {
+	if len(s.ActionTypes) > 0 {
+		actionType, ok := contextActionType(dctx)
+		if !ok {
+			return false, fmt.Sprintf("context has no action type, scope requires one of %v", s.ActionTypes)
+		}
+		if !containsString(s.ActionTypes, actionType) {
+			return false, fmt.Sprintf("action type '%s' not in scope %v", actionType, s.ActionTypes)
+		}
+	}
+
+	if len(s.Tags) > 0 {
+		matched := false
+		for _, tag := range contextTags(dctx) {
+			if containsString(s.Tags, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, fmt.Sprintf("context carries none of the scope tags %v", s.Tags)
+		}
+	}
+
+	if s.Predicate != nil && !s.safePredicate(dctx) {
+		return false, fmt.Sprintf("scope predicate '%s' rejected context", s.PredicateID)
+	}
+
+	return true, ""
+}
+
+// safePredicate evaluates the predicate, recovering a panic as a match
+func (s Scope) safePredicate(dctx *DeterministicContext) (matched bool) {
+	defer func() {
+		if recover() != nil {
+			matched = true
+		}
+	}()
+	return s.Predicate(dctx)
+}
+
+// validate checks that a predicate and its ID are given together
+func (s Scope) validate() error {
+	if s.Predicate != nil && s.PredicateID == "" {
+		return fmt.Errorf("scope predicate requires a PredicateID")
+	}
+	if s.Predicate == nil && s.PredicateID != "" {
+		return fmt.Errorf("scope PredicateID '%s' has no predicate", s.PredicateID)
+	}
+	return nil
+}
+
+// descriptor describes the scope for the registry hash
+func (s Scope) descriptor() map[string]interface{} {
+	actionTypes := append([]string{}, s.ActionTypes...)
+	sort.Strings(actionTypes)
+	tags := append([]string{}, s.Tags...)
+	sort.Strings(tags)
+	return map[string]interface{}{
+		"action_types": actionTypes,
+		"tags":         tags,
+		"predicate":    s.PredicateID,
+	}
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
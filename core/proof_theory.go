@@ -8,21 +8,40 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"time"
+	"maps"
+	"slices"
 )
 
 // GovernanceProof represents a cryptographically verifiable proof of governance decision
 type GovernanceProof struct {
 	// What was evaluated
-	PrimitiveVersions map[string]string `json:"primitive_versions"`
-	EvaluationOrder   []string          `json:"evaluation_order"`
+	PrimitiveVersions map[string]string      `json:"primitive_versions"`
+	EvaluationOrder   []string               `json:"evaluation_order"`
+	ShadowPrimitives  []string               `json:"shadow_primitives,omitempty"`  // Evaluated but not gating
+	SkippedPrimitives []SkippedPrimitive     `json:"skipped_primitives,omitempty"` // Out of scope, not evaluated
+	DependencyEdges   map[string][]string    `json:"dependency_edges,omitempty"`   // Primitive ID -> IDs it depends on
+	PrimitiveLevels   map[string]PolicyLevel `json:"primitive_levels,omitempty"`   // Primitive ID -> hierarchy level that registered it
+	RegistryEpoch     uint64                 `json:"registry_epoch"`
+	RegistryHash      string                 `json:"registry_hash"` // SHA256 of the registry configuration
 
 	// What was decided
-	Decision           bool     `json:"decision"`
-	SignalCommitments  []string `json:"signal_commitments"` // SHA256 hashes of signals
+	Decision          bool     `json:"decision"`
+	Outcome           Outcome  `json:"outcome"`
+	SignalCommitments []string `json:"signal_commitments"` // SHA256 hashes of signals
+
+	// What the decision is conditioned on
+	ObligationCommitments []string        `json:"obligation_commitments,omitempty"` // SHA256 hashes of obligations
+	AdviceCommitments     []string        `json:"advice_commitments,omitempty"`     // SHA256 hashes of advice
+	Validity              *ValidityWindow `json:"validity,omitempty"`               // Logical time in which the decision may be acted on
+
+	// Cost
+	CostBudget   uint64 `json:"cost_budget,omitempty"`   // Per-decision cost budget, if metered
+	CostConsumed uint64 `json:"cost_consumed,omitempty"` // Cost charged against the budget
 
 	// Metadata
-	GeneratedAt int64 `json:"generated_at"` // Logical timestamp
+	GeneratedAt int64     `json:"generated_at"`        // Timestamp read from the clock
+	ClockKind   ClockKind `json:"clock_kind"`          // Kind of clock that stamped the proof
+	CacheHit    bool      `json:"cache_hit,omitempty"` // Reused from the decision cache
 	// How to verify
 }
 
@@ -53,8 +72,57 @@ func (gp *GovernanceProof) CommitSignal(result map[string]interface{}) string {
 	return fmt.Sprintf("%x", sha256.Sum256(data))
 }
 
-// ProofGenerator generates cryptographic proofs for governance decisions
-type ProofGenerator struct{}
+// CommitmentHash returns the SHA256 hash of the canonical encoding of the whole proof
+func (gp *GovernanceProof) CommitmentHash() string {
+	data, _ := json.Marshal(gp)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// clone returns a deep copy of the proof
+func (gp *GovernanceProof) clone() *GovernanceProof {
+	if gp == nil {
+		return nil
+	}
+	copied := *gp
+	copied.PrimitiveVersions = maps.Clone(gp.PrimitiveVersions)
+	copied.EvaluationOrder = slices.Clone(gp.EvaluationOrder)
+	copied.ShadowPrimitives = slices.Clone(gp.ShadowPrimitives)
+	copied.SkippedPrimitives = slices.Clone(gp.SkippedPrimitives)
+	if gp.DependencyEdges != nil {
+		copied.DependencyEdges = make(map[string][]string, len(gp.DependencyEdges))
+		for id, deps := range gp.DependencyEdges {
+			copied.DependencyEdges[id] = slices.Clone(deps)
+		}
+	}
+	copied.PrimitiveLevels = maps.Clone(gp.PrimitiveLevels)
+	copied.SignalCommitments = slices.Clone(gp.SignalCommitments)
+	copied.ObligationCommitments = slices.Clone(gp.ObligationCommitments)
+	copied.AdviceCommitments = slices.Clone(gp.AdviceCommitments)
+	if gp.Validity != nil {
+		window := *gp.Validity
+		copied.Validity = &window
+	}
+	return &copied
+}
+
+// ProofGenerator generates cryptographic proofs for governance decisions.
+// The zero value stamps proofs with WallClock.
+type ProofGenerator struct {
+	clock Clock
+}
+
+// NewProofGenerator creates a proof generator that stamps proofs with clock
+func NewProofGenerator(clock Clock) *ProofGenerator {
+	return &ProofGenerator{clock: clock}
+}
+
+// Clock returns the clock that stamps the generator's proofs
+func (pg *ProofGenerator) Clock() Clock {
+	if pg.clock == nil {
+		return WallClock{}
+	}
+	return pg.clock
+}
 
 // GenerateProof generates a cryptographic proof of governance evaluation
 func (pg *ProofGenerator) GenerateProof(
@@ -63,7 +131,7 @@ func (pg *ProofGenerator) GenerateProof(
 	signals []map[string]interface{},
 	primitiveVersions map[string]string,
 ) *GovernanceProof {
-	return pg.GenerateProofWithTime(decision, evaluatedPrimitives, signals, primitiveVersions, time.Now().UnixNano())
+	return pg.GenerateProofWithClock(decision, evaluatedPrimitives, signals, primitiveVersions, pg.Clock())
 }
 
 // GenerateProofWithTime generates proof with explicit logical time (for deterministic testing)
@@ -73,6 +141,17 @@ func (pg *ProofGenerator) GenerateProofWithTime(
 	signals []map[string]interface{},
 	primitiveVersions map[string]string,
 	logicalTime int64,
+) *GovernanceProof {
+	return pg.GenerateProofWithClock(decision, evaluatedPrimitives, signals, primitiveVersions, NewLogicalClock(logicalTime))
+}
+
+// GenerateProofWithClock generates a proof stamped by clock
+func (pg *ProofGenerator) GenerateProofWithClock(
+	decision bool,
+	evaluatedPrimitives []string,
+	signals []map[string]interface{},
+	primitiveVersions map[string]string,
+	clock Clock,
 ) *GovernanceProof {
 	signalCommitments := make([]string, len(signals))
 	for i, signal := range signals {
@@ -84,7 +163,8 @@ func (pg *ProofGenerator) GenerateProofWithTime(
 		EvaluationOrder:   evaluatedPrimitives,
 		Decision:          decision,
 		SignalCommitments: signalCommitments,
-		GeneratedAt:       logicalTime,
+		GeneratedAt:       clock.Now(),
+		ClockKind:         clock.Kind(),
 	}
 }
 
@@ -97,9 +177,43 @@ func (pg *ProofGenerator) CommitSignal(result map[string]interface{}) string {
 	if ts, ok := result["timestamp"]; ok {
 		signalData["timestamp"] = ts
 	}
+	if outcome, ok := result["outcome"]; ok {
+		signalData["outcome"] = outcome
+	}
+	if obligations, ok := result["obligations"]; ok {
+		signalData["obligations"] = obligations
+	}
+	if advice, ok := result["advice"]; ok {
+		signalData["advice"] = advice
+	}
+	if shadow, ok := result["shadow"]; ok {
+		signalData["shadow"] = shadow
+	}
+	if validity, ok := result["validity"]; ok {
+		signalData["validity"] = validity
+	}
 	data, err := json.Marshal(signalData)
 	if err != nil {
 		return fmt.Sprintf("error:%x", sha256.Sum256([]byte(err.Error())))
 	}
 	return fmt.Sprintf("%x", sha256.Sum256(data))
-}
\ No newline at end of file
+}
+
+// CommitObligation creates cryptographic commitment to an obligation
+func (pg *ProofGenerator) CommitObligation(o Obligation) string {
+	return commitJSON(o)
+}
+
+// CommitAdvice creates cryptographic commitment to advice
+func (pg *ProofGenerator) CommitAdvice(a Advice) string {
+	return commitJSON(a)
+}
+
+// commitJSON returns the SHA256 hash of the JSON encoding of v
+func commitJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("error:%x", sha256.Sum256([]byte(err.Error())))
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
@@ -0,0 +1,97 @@
+/*
+Unit tests for batch governance evaluation.
+*/
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// AmountLimitPrimitive denies contexts whose amount exceeds limit
+type AmountLimitPrimitive struct {
+	limit int64
+}
+
+func (a *AmountLimitPrimitive) Version() string { return "1.0.0" }
+func (a *AmountLimitPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	dc := ctx.(*core.DeterministicContext)
+	amount, _ := dc.GetInt("amount")
+	if amount > a.limit {
+		return map[string]interface{}{
+			"valid":    false,
+			"metadata": map[string]interface{}{"reason": fmt.Sprintf("amount %v exceeds %v", amount, a.limit)},
+		}
+	}
+	return map[string]interface{}{"valid": true, "metadata": map[string]interface{}{}}
+}
+
+// decisionBytes marshals a decision
+func decisionBytes(t *testing.T, d *core.GovernanceDecision) string {
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestEvaluateBatchMatchesSequential(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithBatchWorkers(4))
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("limit", &AmountLimitPrimitive{limit: 50})
+
+	contexts := make([]*core.DeterministicContext, 100)
+	for i := range contexts {
+		contexts[i] = core.NewDeterministicContext(map[string]interface{}{"amount": i}, i)
+	}
+
+	decisions := engine.EvaluateBatch(context.Background(), contexts, core.AtLogicalTime(7))
+
+	assert.Len(t, decisions, len(contexts))
+	for i, ctx := range contexts {
+		assert.Equal(t, i <= 50, decisions[i].Permitted)
+		assert.Equal(t, decisionBytes(t, engine.Evaluate(ctx, core.AtLogicalTime(7))), decisionBytes(t, decisions[i]))
+	}
+}
+
+func TestEvaluateBatchEmpty(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	assert.Empty(t, engine.EvaluateBatch(context.Background(), nil))
+}
+
+func TestEvaluateBatchUsesOneSnapshot(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithBatchWorkers(2))
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	contexts := []*core.DeterministicContext{
+		core.NewDeterministicContext(map[string]interface{}{}, 0),
+		core.NewDeterministicContext(map[string]interface{}{}, 1),
+		core.NewDeterministicContext(map[string]interface{}{}, 2),
+	}
+	decisions := engine.EvaluateBatch(context.Background(), contexts, core.WithMode(core.Exhaustive))
+
+	for _, d := range decisions {
+		assert.Equal(t, engine.RegistryEpoch(), d.Proof.RegistryEpoch)
+		assert.Equal(t, engine.RegistryHash(), d.Proof.RegistryHash)
+	}
+}
+
+func TestEvaluateBatchCancelled(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	goCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	decisions := engine.EvaluateBatch(goCtx, []*core.DeterministicContext{
+		core.NewDeterministicContext(map[string]interface{}{}, 0),
+		core.NewDeterministicContext(map[string]interface{}{}, 1),
+	})
+	for _, d := range decisions {
+		assert.False(t, d.Permitted)
+	}
+}
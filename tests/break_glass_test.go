@@ -0,0 +1,141 @@
+/*
+Unit tests for break-glass overrides.
+*/
+
+package tests
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// overrideAuthorities generates a key pair for each identity
+func overrideAuthorities(t *testing.T, ids ...string) (map[string]ed25519.PublicKey, map[string]ed25519.PrivateKey) {
+	public := make(map[string]ed25519.PublicKey)
+	private := make(map[string]ed25519.PrivateKey)
+	for _, id := range ids {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		assert.NoError(t, err)
+		public[id], private[id] = pub, priv
+	}
+	return public, private
+}
+
+// deniedDecision evaluates an engine with a single denying primitive
+func deniedDecision() *core.GovernanceDecision {
+	engine := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(1)))
+	engine.RegisterPrimitive("limit", &MockPrimitive{name: "limit", version: "1.0.0", valid: false})
+	return engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+}
+
+func TestOverrideWithQuorum(t *testing.T) {
+	public, private := overrideAuthorities(t, "alice", "bob", "carol")
+	bg, err := core.NewBreakGlass(2, public, core.WithOverrideClock(core.NewLogicalClock(9)))
+	assert.NoError(t, err)
+
+	decision := deniedDecision()
+	hash := decision.Proof.CommitmentHash()
+	justification := "INC-42: settlement outage"
+
+	proof, err := bg.Override(decision, justification,
+		core.SignOverride("carol", private["carol"], hash, justification),
+		core.SignOverride("alice", private["alice"], hash, justification),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, core.OverrideProofKind, proof.Kind)
+	assert.Equal(t, hash, proof.OriginalProofHash)
+	assert.Equal(t, core.Deny, proof.OriginalOutcome)
+	assert.Equal(t, decision.FailureReasons, proof.OriginalFailureReasons)
+	assert.Equal(t, []string{"alice", "carol"}, proof.Signers)
+	assert.Len(t, proof.TokenCommitments, 2)
+	assert.Equal(t, justification, proof.Justification)
+	assert.Equal(t, int64(9), proof.GeneratedAt)
+	assert.Len(t, proof.CommitmentHash(), 64)
+
+	// The original decision is still a denial
+	assert.False(t, decision.Permitted)
+	assert.Error(t, decision.EnforceObligations())
+
+	// An override proof does not decode as a permit
+	data, err := json.Marshal(proof)
+	assert.NoError(t, err)
+	var asProof core.GovernanceProof
+	assert.NoError(t, json.Unmarshal(data, &asProof))
+	assert.False(t, asProof.Decision)
+}
+
+func TestOverrideRejectsInvalidTokens(t *testing.T) {
+	public, private := overrideAuthorities(t, "alice", "bob")
+	_, outsider := overrideAuthorities(t, "mallory")
+	bg, err := core.NewBreakGlass(2, public)
+	assert.NoError(t, err)
+
+	decision := deniedDecision()
+	hash := decision.Proof.CommitmentHash()
+	justification := "INC-42"
+
+	forged := core.SignOverride("bob", outsider["mallory"], hash, justification)
+	_, err = bg.Override(decision, justification,
+		core.SignOverride("alice", private["alice"], hash, justification),
+		core.SignOverride("alice", private["alice"], hash, justification),
+		core.SignOverride("mallory", outsider["mallory"], hash, justification),
+		core.SignOverride("bob", private["bob"], "other-proof", justification),
+		core.SignOverride("bob", private["bob"], hash, "something else"),
+		forged,
+	)
+
+	var quorumErr *core.QuorumNotMetError
+	assert.True(t, errors.As(err, &quorumErr))
+	assert.Equal(t, 2, quorumErr.Required)
+	assert.Equal(t, 1, quorumErr.Valid)
+	assert.Len(t, quorumErr.Rejected, 5)
+}
+
+func TestOverrideRequiresDenialAndJustification(t *testing.T) {
+	public, private := overrideAuthorities(t, "alice")
+	bg, err := core.NewBreakGlass(1, public)
+	assert.NoError(t, err)
+
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("ok", &MockPrimitive{name: "ok", version: "1.0.0", valid: true})
+	permit := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	_, err = bg.Override(permit, "why", core.SignOverride("alice", private["alice"], permit.Proof.CommitmentHash(), "why"))
+	assert.Error(t, err)
+
+	denial := deniedDecision()
+	_, err = bg.Override(denial, " ", core.SignOverride("alice", private["alice"], denial.Proof.CommitmentHash(), " "))
+	assert.Error(t, err)
+
+	_, err = bg.Override(nil, "why")
+	assert.Error(t, err)
+}
+
+func TestNewBreakGlassValidatesQuorum(t *testing.T) {
+	public, _ := overrideAuthorities(t, "alice", "bob")
+
+	_, err := core.NewBreakGlass(0, public)
+	assert.Error(t, err)
+	_, err = core.NewBreakGlass(3, public)
+	assert.Error(t, err)
+	_, err = core.NewBreakGlass(1, map[string]ed25519.PublicKey{"alice": []byte("short")})
+	assert.Error(t, err)
+
+	// One signer cannot be registered twice to meet the quorum alone
+	_, err = core.NewBreakGlass(2, map[string]ed25519.PublicKey{"alice": public["alice"], "mallory": public["alice"]})
+	assert.EqualError(t, err, "authorities 'alice' and 'mallory' share a public key")
+}
+
+func TestProofCommitmentHashIsStable(t *testing.T) {
+	first := deniedDecision()
+	second := deniedDecision()
+
+	assert.Equal(t, first.Proof.CommitmentHash(), second.Proof.CommitmentHash())
+	second.Proof.GeneratedAt++
+	assert.NotEqual(t, first.Proof.CommitmentHash(), second.Proof.CommitmentHash())
+}
@@ -0,0 +1,92 @@
+/*
+Unit tests for proof clocks.
+*/
+
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func TestLogicalClockIsLamport(t *testing.T) {
+	clock := core.NewLogicalClock(10)
+	assert.Equal(t, int64(10), clock.Now())
+	assert.Equal(t, int64(11), clock.Now())
+
+	clock.Witness(50)
+	assert.Equal(t, int64(51), clock.Now())
+	clock.Witness(3)
+	assert.Equal(t, int64(52), clock.Now())
+	assert.Equal(t, core.ClockLogical, clock.Kind())
+}
+
+func TestHybridLogicalClockNeverGoesBackwards(t *testing.T) {
+	readings := []int64{100, 100, 90, 200}
+	i := 0
+	clock := core.NewHybridLogicalClock(func() int64 {
+		r := readings[i]
+		i++
+		return r
+	})
+
+	assert.Equal(t, int64(100), clock.Now())
+	assert.Equal(t, int64(101), clock.Now())
+	assert.Equal(t, int64(102), clock.Now())
+	assert.Equal(t, int64(200), clock.Now())
+	assert.Equal(t, core.ClockHybridLogical, clock.Kind())
+}
+
+func TestEngineClockStampsProofs(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(1)))
+	engine.RegisterPrimitive("test", &MockPrimitive{name: "test", version: "1.0.0", valid: true})
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	first := engine.Evaluate(ctx)
+	second := engine.Evaluate(ctx)
+
+	assert.Equal(t, int64(1), first.Proof.GeneratedAt)
+	assert.Equal(t, int64(2), second.Proof.GeneratedAt)
+	assert.Equal(t, core.ClockLogical, first.Proof.ClockKind)
+}
+
+func TestDefaultClockIsWallClock(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("test", &MockPrimitive{name: "test", version: "1.0.0", valid: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.Equal(t, core.ClockWall, decision.Proof.ClockKind)
+	assert.Greater(t, decision.Proof.GeneratedAt, int64(0))
+}
+
+func TestLogicalClockMakesEvaluationReproducible(t *testing.T) {
+	newEngine := func() *core.GovernanceEngine {
+		engine := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(0)))
+		engine.RegisterPrimitive("test", &MockPrimitive{name: "test", version: "1.0.0", valid: true})
+		return engine
+	}
+	ctx := core.NewDeterministicContext(map[string]interface{}{"amount": 5}, 3)
+
+	first, err := json.Marshal(newEngine().Evaluate(ctx))
+	assert.NoError(t, err)
+	second, err := json.Marshal(newEngine().Evaluate(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestPerCallClockOverridesEngineClock(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(1)))
+	engine.RegisterPrimitive("test", &MockPrimitive{name: "test", version: "1.0.0", valid: true})
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	assert.Equal(t, int64(500), engine.Evaluate(ctx, core.AtLogicalTime(500)).Proof.GeneratedAt)
+	assert.Equal(t, int64(7), engine.Evaluate(ctx, core.UsingClock(core.NewHybridLogicalClock(func() int64 { return 7 }))).Proof.GeneratedAt)
+	assert.Equal(t, core.ClockHybridLogical, engine.Evaluate(ctx, core.UsingClock(core.NewHybridLogicalClock(nil))).Proof.ClockKind)
+	assert.Equal(t, int64(12345), engine.EvaluateWithLogicalTime(ctx, 12345).Proof.GeneratedAt)
+	// The engine clock was not advanced by the overridden calls
+	assert.Equal(t, int64(1), engine.Evaluate(ctx).Proof.GeneratedAt)
+}
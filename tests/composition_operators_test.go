@@ -95,4 +95,53 @@ func TestThresholdNotMet(t *testing.T) {
 	result := composed.Evaluate(nil)
 
 	assert.False(t, result["valid"].(bool))
-}
\ No newline at end of file
+}
+
+func TestSequentialAndRecoversPanic(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.SequentialAnd([]core.GovernancePrimitive{
+		&MockPrimitive{name: "pass", version: "1.0", valid: true},
+		&PanickingPrimitive{name: "buggy", version: "2.0", value: "boom"},
+	})
+
+	var result map[string]interface{}
+	assert.NotPanics(t, func() { result = composed.Evaluate(nil) })
+
+	assert.False(t, result["valid"].(bool))
+	meta := result["metadata"].(map[string]interface{})
+	assert.Equal(t, string(core.FailurePanic), meta["failure"])
+	details := meta["panic"].(map[string]interface{})
+	assert.Equal(t, "buggy", details["primitive_id"])
+	assert.Equal(t, "2.0", details["version"])
+	assert.Equal(t, "boom", details["panic"])
+}
+
+func TestParallelAndRecoversPanic(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.ParallelAnd([]core.GovernancePrimitive{
+		&PanickingPrimitive{name: "buggy", version: "1.0", value: "boom"},
+		&MockPrimitive{name: "pass", version: "1.0", valid: true},
+	})
+	result := composed.Evaluate(nil)
+
+	assert.False(t, result["valid"].(bool))
+	meta := result["metadata"].(map[string]interface{})
+	assert.Len(t, meta["panics"], 1)
+}
+
+func TestThresholdCountsPanicAsFailure(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.Threshold([]core.GovernancePrimitive{
+		&MockPrimitive{name: "a", version: "1.0", valid: true},
+		&PanickingPrimitive{name: "buggy", version: "1.0", value: "boom"},
+	}, 2)
+	result := composed.Evaluate(nil)
+
+	assert.False(t, result["valid"].(bool))
+	meta := result["metadata"].(map[string]interface{})
+	assert.Contains(t, meta["reason"], "Only 1 of 2")
+	assert.Len(t, meta["panics"], 1)
+}
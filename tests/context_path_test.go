@@ -0,0 +1,189 @@
+/*
+Unit tests for path queries over DeterministicContext.
+*/
+
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// orderContext holds nested maps and lists to query
+func orderContext() *core.DeterministicContext {
+	amount, _ := core.ParseDecimal("1250.75")
+	return core.NewDeterministicContext(map[string]interface{}{
+		"customer": map[string]interface{}{
+			"name":    "alice",
+			"address": map[string]interface{}{"country": "NZ"},
+		},
+		"orders": []interface{}{
+			map[string]interface{}{"id": "o-1", "amount": amount, "lines": []interface{}{1, 2}},
+			map[string]interface{}{"id": "o-2", "amount": 300, "lines": []interface{}{3}},
+		},
+		"meta": map[string]interface{}{"a.b": true, "x/y": "slash", "m~n": "tilde"},
+	}, 0)
+}
+
+func TestQueryDottedPaths(t *testing.T) {
+	ctx := orderContext()
+
+	name, err := ctx.QueryString("customer.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", name)
+
+	country, err := ctx.QueryString("customer.address.country")
+	assert.NoError(t, err)
+	assert.Equal(t, "NZ", country)
+
+	amount, err := ctx.QueryDecimal("orders[0].amount")
+	assert.NoError(t, err)
+	assert.Equal(t, "1250.75", amount.String())
+
+	line, err := ctx.QueryInt("orders[1].lines[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), line)
+
+	quoted, err := ctx.QueryBool(`meta["a.b"]`)
+	assert.NoError(t, err)
+	assert.True(t, quoted)
+
+	kind, err := ctx.QueryKind("orders")
+	assert.NoError(t, err)
+	assert.Equal(t, core.KindList, kind)
+}
+
+func TestQueryJSONPointer(t *testing.T) {
+	ctx := orderContext()
+
+	id, err := ctx.QueryString("/orders/1/id")
+	assert.NoError(t, err)
+	assert.Equal(t, "o-2", id)
+
+	slash, err := ctx.QueryString("/meta/x~1y")
+	assert.NoError(t, err)
+	assert.Equal(t, "slash", slash)
+
+	tilde, err := ctx.QueryString("/meta/m~0n")
+	assert.NoError(t, err)
+	assert.Equal(t, "tilde", tilde)
+
+	whole, err := ctx.Query("")
+	assert.NoError(t, err)
+	assert.Equal(t, ctx.Data(), whole)
+
+	// Array indices may not have leading zeros, and "-" is past the end
+	for _, path := range []string{"/orders/01/id", "/orders/-"} {
+		_, err = ctx.Query(path)
+		var notFound *core.PathNotFoundError
+		assert.True(t, errors.As(err, &notFound), path)
+	}
+}
+
+func TestQueryWildcardProjection(t *testing.T) {
+	ctx := orderContext()
+
+	ids, err := ctx.QueryStrings("orders[*].id")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"o-1", "o-2"}, ids)
+
+	amounts, err := ctx.QueryDecimals("orders[*].amount")
+	assert.NoError(t, err)
+	assert.Equal(t, "1250.75", amounts[0].String())
+	assert.Equal(t, "300", amounts[1].String())
+
+	// Nested wildcards are flattened in order
+	lines, err := ctx.QueryInts("orders[*].lines[*]")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, lines)
+
+	projected, err := ctx.Query("orders[*].lines")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{int64(3)}}, projected)
+
+	// A typed single-value query refuses a wildcard
+	_, err = ctx.QueryString("orders[*].id")
+	var invalid *core.InvalidPathError
+	assert.True(t, errors.As(err, &invalid))
+}
+
+func TestQueryNotFound(t *testing.T) {
+	ctx := orderContext()
+
+	cases := map[string]string{
+		"customer.email":           "customer.email",
+		"orders[5].id":             "orders[5]",
+		"orders[0].discount.total": "orders[0].discount",
+		"/customer/address/city":   "/customer/address/city",
+		"orders[*].lines[1]":       "orders[1].lines[1]",
+	}
+	for path, at := range cases {
+		_, err := ctx.Query(path)
+		var notFound *core.PathNotFoundError
+		if assert.True(t, errors.As(err, &notFound), path) {
+			assert.Equal(t, path, notFound.Path)
+			assert.Equal(t, at, notFound.At)
+		}
+	}
+}
+
+func TestQueryWrongType(t *testing.T) {
+	ctx := orderContext()
+
+	// Stepping into a scalar
+	_, err := ctx.Query("customer.name.first")
+	var mismatch *core.TypeMismatchError
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "customer.name", mismatch.Key)
+		assert.Equal(t, core.KindMap, mismatch.Expected)
+		assert.Equal(t, core.KindString, mismatch.Actual)
+	}
+
+	// Indexing a map
+	_, err = ctx.Query("customer[0]")
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, core.KindList, mismatch.Expected)
+		assert.Equal(t, core.KindMap, mismatch.Actual)
+	}
+
+	// The selected value has the wrong kind, reported at its concrete path
+	_, err = ctx.QueryInts("orders[*].id")
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "orders[0].id", mismatch.Key)
+		assert.Equal(t, core.KindInt, mismatch.Expected)
+	}
+	_, err = ctx.QueryInt("/orders/0/amount")
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "/orders/0/amount", mismatch.Key)
+		assert.Equal(t, core.KindDecimal, mismatch.Actual)
+	}
+}
+
+func TestQueryInvalidPaths(t *testing.T) {
+	ctx := orderContext()
+
+	for _, path := range []string{".a", "a.", "a..b", "a[", "a[x]", "a[-1]", "a[0]b", `a["b]`, "/a~2"} {
+		_, err := ctx.Query(path)
+		var invalid *core.InvalidPathError
+		assert.True(t, errors.As(err, &invalid), path)
+	}
+}
+
+func TestQueryReturnsCopies(t *testing.T) {
+	ctx := orderContext()
+
+	order, err := ctx.QueryMap("orders[0]")
+	assert.NoError(t, err)
+	order["id"] = "changed"
+	lines, err := ctx.QueryList("orders[0].lines")
+	assert.NoError(t, err)
+	lines[0] = "changed"
+
+	id, _ := ctx.QueryString("orders[0].id")
+	assert.Equal(t, "o-1", id)
+	first, _ := ctx.QueryInt("orders[0].lines[0]")
+	assert.Equal(t, int64(1), first)
+}
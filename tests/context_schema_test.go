@@ -0,0 +1,269 @@
+/*
+Unit tests for declared context schemas.
+*/
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// SchemaMockPrimitive permits, declaring the context schema it requires
+type SchemaMockPrimitive struct {
+	schema *core.Schema
+	calls  atomic.Int64
+}
+
+func (s *SchemaMockPrimitive) Version() string             { return "1.0.0" }
+func (s *SchemaMockPrimitive) ContextSchema() *core.Schema { return s.schema }
+func (s *SchemaMockPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	s.calls.Add(1)
+	return map[string]interface{}{"valid": true, "metadata": map[string]interface{}{}}
+}
+
+// mustParseSchema parses a schema document
+func mustParseSchema(t *testing.T, doc string) *core.Schema {
+	schema, err := core.ParseSchema([]byte(doc))
+	assert.NoError(t, err)
+	return schema
+}
+
+const paymentSchema = `{
+	"type": "object",
+	"required": ["amount", "currency", "payee"],
+	"properties": {
+		"amount": {"type": "number", "minimum": 0.01, "maximum": 1000000},
+		"currency": {"type": "string", "enum": ["NZD", "USD"]},
+		"payee": {
+			"type": "object",
+			"required": ["account"],
+			"properties": {"account": {"type": "string", "pattern": "^[0-9]{2}-[0-9]{4}$"}}
+		},
+		"lines": {"type": "array", "maxItems": 2, "items": {"type": "integer"}}
+	}
+}`
+
+// payment returns a context satisfying paymentSchema, with overrides applied
+func payment(overrides map[string]interface{}) *core.DeterministicContext {
+	amount, _ := core.ParseDecimal("250.00")
+	data := map[string]interface{}{
+		"amount":   amount,
+		"currency": "NZD",
+		"payee":    map[string]interface{}{"account": "12-3456"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(data, k)
+			continue
+		}
+		data[k] = v
+	}
+	return core.NewDeterministicContext(data, 0)
+}
+
+func TestSchemaAcceptsValidContext(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	p := &SchemaMockPrimitive{schema: mustParseSchema(t, paymentSchema)}
+	assert.NoError(t, engine.RegisterPrimitive("payments", p))
+
+	decision := engine.Evaluate(payment(map[string]interface{}{"lines": []interface{}{1, 2}}))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, int64(1), p.calls.Load())
+}
+
+func TestSchemaViolationDeniesBeforeEvaluation(t *testing.T) {
+	cases := []struct {
+		overrides map[string]interface{}
+		field     string
+		reason    string
+	}{
+		{map[string]interface{}{"amount": nil}, "amount", "missing required field"},
+		{map[string]interface{}{"amount": "250"}, "amount", "expected number, got string"},
+		{map[string]interface{}{"amount": 0}, "amount", "0 is less than the minimum 0.01"},
+		{map[string]interface{}{"currency": "EUR"}, "currency", "value is not one of the allowed values"},
+		{map[string]interface{}{"payee": map[string]interface{}{}}, "payee.account", "missing required field"},
+		{map[string]interface{}{"payee": map[string]interface{}{"account": "123"}}, "payee.account", `does not match pattern "^[0-9]{2}-[0-9]{4}$"`},
+		{map[string]interface{}{"lines": []interface{}{1, 2.5}}, "lines[1]", "expected integer, got float"},
+		{map[string]interface{}{"lines": []interface{}{1, 2, 3}}, "lines", "has 3 items, more than 2"},
+	}
+	for _, c := range cases {
+		engine := core.NewGovernanceEngine()
+		p := &SchemaMockPrimitive{schema: mustParseSchema(t, paymentSchema)}
+		engine.RegisterPrimitive("payments", p)
+		other := &CountingPrimitive{version: "1.0.0"}
+		engine.RegisterPrimitive("other", other)
+
+		decision := engine.Evaluate(payment(c.overrides))
+
+		assert.False(t, decision.Permitted, c.field)
+		assert.Equal(t, int64(0), p.calls.Load(), c.field)
+		assert.Equal(t, int64(0), other.calls.Load(), c.field)
+		if assert.Len(t, decision.FailureReasons, 1, c.field) {
+			assert.Contains(t, decision.FailureReasons[0], "Primitive 'payments' failed")
+			assert.Contains(t, decision.FailureReasons[0], "context field '"+c.field+"': "+c.reason)
+		}
+		meta := decision.Signals[0]["metadata"].(map[string]interface{})
+		assert.Equal(t, "schema_violation", meta["failure"])
+		assert.Equal(t, c.field, meta["field"])
+		assert.Equal(t, "payments", meta["primitive_id"])
+	}
+}
+
+func TestSchemaViolationKeepsEvaluationOrder(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	first := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("first", first)
+	engine.RegisterPrimitive("deny", &MockPrimitive{name: "deny", version: "1.0.0", valid: false})
+	p := &SchemaMockPrimitive{schema: mustParseSchema(t, paymentSchema)}
+	engine.RegisterPrimitive("payments", p)
+	last := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("last", last)
+	ctx := payment(map[string]interface{}{"amount": nil})
+
+	// Fail-fast reports the violation of a later primitive before evaluating any
+	decision := engine.Evaluate(ctx)
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"payments"}, decision.Proof.EvaluationOrder)
+	if assert.Len(t, decision.FailureReasons, 1) {
+		assert.Contains(t, decision.FailureReasons[0], "context field 'amount'")
+	}
+	assert.Equal(t, int64(0), first.calls.Load())
+
+	// Exhaustive evaluation records the violation in its place and evaluates the rest
+	decision = engine.EvaluateContext(context.Background(), ctx, core.WithMode(core.Exhaustive))
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"first", "deny", "payments", "last"}, decision.Proof.EvaluationOrder)
+	assert.Len(t, decision.FailureReasons, 2)
+	assert.Contains(t, decision.FailureReasons[1], "context field 'amount'")
+	assert.Equal(t, int64(1), first.calls.Load())
+	assert.Equal(t, int64(1), last.calls.Load())
+	assert.Equal(t, int64(0), p.calls.Load())
+}
+
+func TestSchemaReportsEveryViolation(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("payments", &SchemaMockPrimitive{schema: mustParseSchema(t, paymentSchema)})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"currency": 7}, 0))
+
+	meta := decision.Signals[0]["metadata"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"amount", "currency", "payee"}, meta["fields"])
+	assert.Len(t, meta["violations"], 3)
+}
+
+func TestSchemaValidatesUnionOfApplicablePrimitives(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("amount", &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"amount"}}})
+	engine.RegisterPrimitive("region", &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"region"}}})
+	engine.RegisterPrimitive("trades", &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"desk"}}},
+		core.WithScope(core.Scope{ActionTypes: []string{"trade"}}))
+
+	// The trades primitive does not apply, so its schema is not required
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{
+		"amount": 1, "region": "nz", core.ActionTypeKey: "payment",
+	}, 0))
+	assert.True(t, decision.Permitted)
+
+	decision = engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{
+		"amount": 1, core.ActionTypeKey: "payment",
+	}, 0))
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.FailureReasons, 1)
+	assert.Contains(t, decision.FailureReasons[0], "Primitive 'region' failed")
+	assert.Contains(t, decision.FailureReasons[0], "context field 'region'")
+}
+
+func TestShadowSchemaViolationDoesNotGate(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	gate := &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"amount"}}}
+	shadow := &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"risk_score"}}}
+	engine.RegisterPrimitive("gate", gate)
+	engine.RegisterPrimitive("candidate", shadow, core.AsShadow())
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"amount": 1}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Empty(t, decision.FailureReasons)
+	if assert.Len(t, decision.ShadowFailures, 1) {
+		assert.Contains(t, decision.ShadowFailures[0], "context field 'risk_score': missing required field")
+	}
+	assert.Equal(t, int64(1), gate.calls.Load())
+	assert.Equal(t, int64(0), shadow.calls.Load())
+}
+
+func TestCompositeChildSchemasAreValidated(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	composite := composer.SequentialAnd([]core.GovernancePrimitive{
+		&SchemaMockPrimitive{schema: &core.Schema{Required: []string{"amount"}}},
+		&SchemaMockPrimitive{schema: &core.Schema{Required: []string{"account"}}},
+	})
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("both", composite)
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"amount": 1}, 0))
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "context field 'account'")
+}
+
+func TestSchemaIsCheckedAfterEnrichment(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.AddInterceptor(&RecordingInterceptor{name: "enrich", calls: &[]string{}, enrich: map[string]interface{}{"risk": "low"}})
+	engine.RegisterPrimitive("risk", &SchemaMockPrimitive{schema: &core.Schema{
+		Required:   []string{"risk"},
+		Properties: map[string]*core.Schema{"risk": {Type: "string", Enum: []interface{}{"low", "high"}}},
+	}})
+
+	assert.True(t, engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0)).Permitted)
+}
+
+func TestSchemaRejectsAdditionalProperties(t *testing.T) {
+	closed := false
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("strict", &SchemaMockPrimitive{schema: &core.Schema{
+		Properties: map[string]*core.Schema{
+			"payee": {Type: "object", AdditionalProperties: &closed, Properties: map[string]*core.Schema{"account": {Type: "string"}}},
+		},
+	}})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{
+		"payee": map[string]interface{}{"account": "12-3456", "note": "x"},
+	}, 0))
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "context field 'payee.note': unexpected field")
+}
+
+func TestInvalidSchemasAreRejectedAtRegistration(t *testing.T) {
+	_, err := core.ParseSchema([]byte(`{"type": "object", "format": "email"}`))
+	assert.Error(t, err)
+	_, err = core.ParseSchema([]byte(`{"type": "tuple"}`))
+	assert.Error(t, err)
+
+	engine := core.NewGovernanceEngine()
+	for _, schema := range []*core.Schema{
+		{Type: "string"},
+		{Properties: map[string]*core.Schema{"id": {Pattern: "("}}},
+		{Properties: map[string]*core.Schema{"id": nil}},
+	} {
+		err := engine.RegisterPrimitive("bad", &SchemaMockPrimitive{schema: schema})
+		if assert.Error(t, err) {
+			assert.True(t, strings.HasPrefix(err.Error(), "primitive 'bad' declares an invalid context schema"))
+		}
+	}
+	assert.Equal(t, 0, engine.PrimitiveCount())
+}
+
+func TestSchemaIsPartOfRegistryHash(t *testing.T) {
+	loose := core.NewGovernanceEngine()
+	loose.RegisterPrimitive("p", &SchemaMockPrimitive{schema: &core.Schema{}})
+	strict := core.NewGovernanceEngine()
+	strict.RegisterPrimitive("p", &SchemaMockPrimitive{schema: &core.Schema{Required: []string{"amount"}}})
+
+	assert.NotEqual(t, loose.RegistryHash(), strict.RegistryHash())
+}
@@ -0,0 +1,131 @@
+/*
+Unit tests for fail-closed context construction.
+*/
+
+package tests
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// validationError asserts that err is a *ContextValidationError and returns it
+func validationError(t *testing.T, err error) *core.ContextValidationError {
+	var invalid *core.ContextValidationError
+	assert.True(t, errors.As(err, &invalid), "expected a validation error, got %v", err)
+	if invalid == nil {
+		return &core.ContextValidationError{}
+	}
+	return invalid
+}
+
+func TestBuildContextRejectsUnsupportedValues(t *testing.T) {
+	cases := []struct {
+		data   map[string]interface{}
+		path   string
+		reason string
+	}{
+		{map[string]interface{}{"ch": make(chan int)}, "ch", "unsupported value of type chan int"},
+		{map[string]interface{}{"fn": func() {}}, "fn", "unsupported value of type func()"},
+		{map[string]interface{}{"rate": math.NaN()}, "rate", "non-finite number NaN"},
+		{map[string]interface{}{"order": map[string]interface{}{"amount": math.Inf(1)}}, "order.amount", "non-finite number +Inf"},
+		{map[string]interface{}{"orders": []interface{}{1, map[string]interface{}{"fee": math.Inf(-1)}}}, "orders[1].fee", "non-finite number -Inf"},
+		{map[string]interface{}{"n": []float64{1, math.NaN()}}, "n[1]", "non-finite number NaN"},
+		{map[string]interface{}{"rates": map[string][]float32{"eur": {float32(math.Inf(1))}}}, "rates.eur[0]", "non-finite number +Inf"},
+	}
+	for _, c := range cases {
+		ctx, err := core.BuildDeterministicContext(c.data, 0)
+		assert.Nil(t, ctx)
+		invalid := validationError(t, err)
+		assert.Equal(t, c.path, invalid.Path)
+		assert.Equal(t, c.reason, invalid.Reason)
+	}
+}
+
+func TestBuildContextEnforcesDepth(t *testing.T) {
+	data := map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"c"}}}
+
+	_, err := core.BuildDeterministicContext(data, 0, core.WithMaxDepth(3))
+	assert.NoError(t, err)
+
+	_, err = core.BuildDeterministicContext(data, 0, core.WithMaxDepth(2))
+	invalid := validationError(t, err)
+	assert.Equal(t, "a.b[0]", invalid.Path)
+	assert.Contains(t, invalid.Reason, "maximum nesting depth of 2")
+
+	// Self-referencing data is stopped by the default depth
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	_, err = core.BuildDeterministicContext(cyclic, 0)
+	invalid = validationError(t, err)
+	assert.True(t, strings.HasPrefix(invalid.Path, "self.self."))
+}
+
+func TestBuildContextEnforcesKeyCount(t *testing.T) {
+	data := map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2, "d": 3}}
+
+	_, err := core.BuildDeterministicContext(data, 0, core.WithMaxKeys(4))
+	assert.NoError(t, err)
+
+	_, err = core.BuildDeterministicContext(data, 0, core.WithMaxKeys(3))
+	invalid := validationError(t, err)
+	assert.Equal(t, "b.d", invalid.Path)
+	assert.Contains(t, invalid.Reason, "maximum of 3 keys")
+}
+
+func TestBuildContextEnforcesSize(t *testing.T) {
+	data := map[string]interface{}{"id": "abc", "note": strings.Repeat("x", 100)}
+
+	// Keys and strings count their length
+	_, err := core.BuildDeterministicContext(data, 0, core.WithMaxBytes(109))
+	assert.NoError(t, err)
+
+	_, err = core.BuildDeterministicContext(data, 0, core.WithMaxBytes(108))
+	invalid := validationError(t, err)
+	assert.Equal(t, "note", invalid.Path)
+	assert.Contains(t, invalid.Reason, "maximum size of 108 bytes")
+}
+
+func TestNewContextRecordsValidationError(t *testing.T) {
+	ctx := core.NewDeterministicContext(map[string]interface{}{"ok": 1, "bad": math.NaN()}, 0)
+
+	assert.Equal(t, 0, ctx.Len())
+	invalid := validationError(t, ctx.Err())
+	assert.Equal(t, "bad", invalid.Path)
+
+	assert.NoError(t, core.NewDeterministicContext(map[string]interface{}{"ok": 1}, 0).Err())
+}
+
+func TestEngineDeniesInvalidContext(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	calls := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counting", calls)
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"rate": math.Inf(1)}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"invalid_context: rate: non-finite number +Inf"}, decision.FailureReasons)
+	assert.Empty(t, decision.Signals)
+	assert.Equal(t, int64(0), calls.calls.Load())
+}
+
+func TestEnrichmentRespectsContextLimits(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.AddInterceptor(&RecordingInterceptor{name: "enrich", calls: &[]string{}, enrich: map[string]interface{}{"extra": 1}})
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	full, err := core.BuildDeterministicContext(map[string]interface{}{"a": 1}, 0, core.WithMaxKeys(1))
+	assert.NoError(t, err)
+	decision := engine.Evaluate(full)
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "extra: context exceeds the maximum of 1 keys")
+
+	roomy, err := core.BuildDeterministicContext(map[string]interface{}{"a": 1}, 0, core.WithMaxKeys(2))
+	assert.NoError(t, err)
+	assert.True(t, engine.Evaluate(roomy).Permitted)
+}
@@ -0,0 +1,256 @@
+/*
+Unit tests for typed context values.
+*/
+
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// typedContext holds one value of every kind
+func typedContext() *core.DeterministicContext {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	amount, _ := core.ParseDecimal("1000000000000000.01")
+	return core.NewDeterministicContext(map[string]interface{}{
+		"int":     int64(math.MaxInt64),
+		"uint":    uint64(math.MaxUint64),
+		"big":     huge,
+		"amount":  amount,
+		"ratio":   0.25,
+		"name":    "alice",
+		"active":  true,
+		"payload": []byte{0x00, 0xff},
+		"limits":  map[string]interface{}{"daily": 10},
+		"tags":    []interface{}{"a", 1},
+		"missing": nil,
+	}, 0)
+}
+
+func TestContextPreservesTypes(t *testing.T) {
+	ctx := typedContext()
+
+	kinds := map[string]core.ValueKind{
+		"int":     core.KindInt,
+		"uint":    core.KindUint,
+		"big":     core.KindBigInt,
+		"amount":  core.KindDecimal,
+		"ratio":   core.KindFloat,
+		"name":    core.KindString,
+		"active":  core.KindBool,
+		"payload": core.KindBytes,
+		"limits":  core.KindMap,
+		"tags":    core.KindList,
+		"missing": core.KindNull,
+	}
+	for key, want := range kinds {
+		kind, err := ctx.Kind(key)
+		assert.NoError(t, err)
+		assert.Equal(t, want, kind, key)
+	}
+
+	assert.Equal(t, int64(math.MaxInt64), ctx.Get("int", nil))
+	assert.Equal(t, uint64(math.MaxUint64), ctx.Get("uint", nil))
+	assert.Equal(t, "123456789012345678901234567890", ctx.Get("big", nil).(*big.Int).String())
+	assert.Equal(t, "1000000000000000.01", ctx.Get("amount", nil).(core.Decimal).String())
+	assert.Equal(t, []byte{0x00, 0xff}, ctx.Get("payload", nil))
+	assert.Equal(t, map[string]interface{}{"daily": int64(10)}, ctx.Get("limits", nil))
+}
+
+// Level is a named basic type, as callers often use for enumerations
+type Level int32
+
+func TestTypedCollectionsPreserveElementTypes(t *testing.T) {
+	amount := 2.5
+	ctx, err := core.BuildDeterministicContext(map[string]interface{}{
+		"uints":   []uint64{5},
+		"floats":  []float64{1.5},
+		"ints":    [2]int8{-1, 2},
+		"weights": map[string]float64{"x": 1.5},
+		"nested":  map[string][]uint32{"ids": {7}},
+		"levels":  []Level{3},
+		"amount":  &amount,
+	}, 0)
+	assert.NoError(t, err)
+
+	kinds := map[string]core.ValueKind{
+		"uints[0]":      core.KindUint,
+		"floats[0]":     core.KindFloat,
+		"ints[1]":       core.KindInt,
+		"weights.x":     core.KindFloat,
+		"nested.ids[0]": core.KindUint,
+		"levels[0]":     core.KindInt,
+		"amount":        core.KindFloat,
+	}
+	for path, want := range kinds {
+		kind, err := ctx.QueryKind(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, want, kind, path)
+	}
+	assert.Equal(t, []interface{}{uint64(5)}, ctx.Get("uints", nil))
+	assert.Equal(t, map[string]interface{}{"x": 1.5}, ctx.Get("weights", nil))
+	assert.Equal(t, []interface{}{int64(-1), int64(2)}, ctx.Get("ints", nil))
+}
+
+func TestTypedAccessors(t *testing.T) {
+	ctx := typedContext()
+
+	i, err := ctx.GetInt("int")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), i)
+
+	u, err := ctx.GetUint("uint")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), u)
+
+	b, err := ctx.GetBigInt("big")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890", b.String())
+
+	d, err := ctx.GetDecimal("amount")
+	assert.NoError(t, err)
+	assert.Equal(t, "1000000000000000.01", d.String())
+
+	f, err := ctx.GetFloat("ratio")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, f)
+
+	s, err := ctx.GetString("name")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", s)
+
+	active, err := ctx.GetBool("active")
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	m, err := ctx.GetMap("limits")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"daily": int64(10)}, m)
+
+	l, err := ctx.GetList("tags")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(1)}, l)
+}
+
+func TestTypedAccessorsConvertIntegersLosslessly(t *testing.T) {
+	ctx := typedContext()
+
+	u, err := ctx.GetUint("int")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxInt64), u)
+
+	b, err := ctx.GetBigInt("uint")
+	assert.NoError(t, err)
+	assert.Equal(t, "18446744073709551615", b.String())
+
+	d, err := ctx.GetDecimal("big")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890", d.String())
+
+	// Out of range or inexact conversions are refused
+	_, err = ctx.GetInt("uint")
+	assert.Error(t, err)
+	_, err = ctx.GetInt("big")
+	assert.Error(t, err)
+	_, err = ctx.GetDecimal("ratio")
+	assert.Error(t, err)
+	_, err = ctx.GetFloat("int")
+	assert.Error(t, err)
+}
+
+func TestTypedAccessorErrors(t *testing.T) {
+	ctx := typedContext()
+
+	_, err := ctx.GetString("int")
+	var mismatch *core.TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "int", mismatch.Key)
+	assert.Equal(t, core.KindString, mismatch.Expected)
+	assert.Equal(t, core.KindInt, mismatch.Actual)
+	assert.Equal(t, "key 'int' holds int, not string", err.Error())
+
+	_, err = ctx.GetInt("absent")
+	var notFound *core.KeyNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "absent", notFound.Key)
+
+	_, err = ctx.GetItem("absent")
+	assert.True(t, errors.As(err, &notFound))
+	_, err = ctx.Kind("absent")
+	assert.True(t, errors.As(err, &notFound))
+}
+
+func TestTypedValuesAreCopies(t *testing.T) {
+	payload := []byte("secret")
+	huge := big.NewInt(42)
+	ctx := core.NewDeterministicContext(map[string]interface{}{"payload": payload, "big": huge}, 0)
+
+	payload[0] = 'X'
+	huge.SetInt64(0)
+	got, _ := ctx.GetBytes("payload")
+	got[1] = 'X'
+	b, _ := ctx.GetBigInt("big")
+	b.SetInt64(7)
+
+	again, _ := ctx.GetBytes("payload")
+	assert.Equal(t, "secret", string(again))
+	b, _ = ctx.GetBigInt("big")
+	assert.Equal(t, int64(42), b.Int64())
+}
+
+func TestJSONNumbersKeepPrecision(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"small": 7, "large": 18446744073709551615, "huge": 100000000000000000000, "amount": 0.10}`))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	assert.NoError(t, decoder.Decode(&data))
+	ctx := core.NewDeterministicContext(data, 0)
+
+	assert.Equal(t, int64(7), ctx.Get("small", nil))
+	assert.Equal(t, uint64(math.MaxUint64), ctx.Get("large", nil))
+	huge, err := ctx.GetBigInt("huge")
+	assert.NoError(t, err)
+	assert.Equal(t, "100000000000000000000", huge.String())
+	amount, err := ctx.GetDecimal("amount")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.10", amount.String())
+}
+
+func TestContextHashIsTypeTagged(t *testing.T) {
+	hash := func(v interface{}) string {
+		h, err := core.NewDeterministicContext(map[string]interface{}{"v": v}, 0).Hash()
+		assert.NoError(t, err)
+		return h
+	}
+
+	hashes := map[string]string{}
+	for name, v := range map[string]interface{}{
+		"int":     int64(1),
+		"uint":    uint64(1),
+		"big":     big.NewInt(1),
+		"decimal": core.NewDecimal(1, 0),
+		"float":   1.0,
+		"string":  "1",
+		"bytes":   []byte("1"),
+		"list":    []interface{}{int64(1)},
+	} {
+		h := hash(v)
+		for other, existing := range hashes {
+			assert.NotEqual(t, existing, h, "%s and %s hash alike", name, other)
+		}
+		hashes[name] = h
+	}
+
+	// Decimals that differ only in trailing zeros are the same number
+	a, _ := core.ParseDecimal("1.50")
+	b, _ := core.ParseDecimal("1.5")
+	assert.Equal(t, hash(a), hash(b))
+	assert.Equal(t, hash(7), hash(int64(7)))
+}
@@ -0,0 +1,99 @@
+/*
+Unit tests for read-only views of DeterministicContext values.
+*/
+
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func TestViewReadsNestedValues(t *testing.T) {
+	ctx := orderContext()
+
+	orders, err := ctx.View("orders")
+	assert.NoError(t, err)
+	assert.Equal(t, core.KindList, orders.Kind())
+	assert.Equal(t, 2, orders.Len())
+
+	order, err := orders.Index(0)
+	assert.NoError(t, err)
+	keys, err := order.Keys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"amount", "id", "lines"}, keys)
+
+	amount, err := order.Field("amount")
+	assert.NoError(t, err)
+	assert.Equal(t, "orders[0].amount", amount.Path())
+	d, err := amount.AsDecimal()
+	assert.NoError(t, err)
+	assert.Equal(t, "1250.75", d.String())
+
+	line, err := order.Field("lines")
+	assert.NoError(t, err)
+	line, err = line.Index(1)
+	assert.NoError(t, err)
+	n, err := line.AsInt()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// A view agrees with the copying accessors
+	orders, _ = ctx.View("orders")
+	assert.Equal(t, ctx.Get("orders", nil), orders.Value())
+}
+
+func TestQueryViewKeepsPathSyntax(t *testing.T) {
+	ctx := orderContext()
+
+	customer, err := ctx.QueryView("/customer")
+	assert.NoError(t, err)
+	country, err := customer.Field("address")
+	assert.NoError(t, err)
+	country, err = country.Field("country")
+	assert.NoError(t, err)
+	assert.Equal(t, "/customer/address/country", country.Path())
+	s, err := country.AsString()
+	assert.NoError(t, err)
+	assert.Equal(t, "NZ", s)
+
+	meta, err := ctx.QueryView("meta")
+	assert.NoError(t, err)
+	dotted, err := meta.Field("a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, `meta["a.b"]`, dotted.Path())
+
+	_, err = ctx.QueryView("orders[*].id")
+	var invalid *core.InvalidPathError
+	assert.True(t, errors.As(err, &invalid))
+}
+
+func TestViewErrors(t *testing.T) {
+	ctx := orderContext()
+
+	_, err := ctx.View("absent")
+	var notFound *core.KeyNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	orders, _ := ctx.View("orders")
+	_, err = orders.Index(5)
+	var missing *core.PathNotFoundError
+	assert.True(t, errors.As(err, &missing))
+	assert.Equal(t, "orders[5]", missing.Path)
+
+	_, err = orders.Field("id")
+	var mismatch *core.TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "orders", mismatch.Key)
+	assert.Equal(t, core.KindMap, mismatch.Expected)
+
+	id, _ := ctx.QueryView("orders[1].id")
+	_, err = id.AsInt()
+	assert.EqualError(t, err, "key 'orders[1].id' holds string, not int")
+	_, err = id.Keys()
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, 0, id.Len())
+}
@@ -0,0 +1,152 @@
+/*
+Unit tests for evaluation cost budgets.
+*/
+
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// CostlyPrimitive declares a cost, counts its evaluations and permits
+type CostlyPrimitive struct {
+	cost  uint64
+	calls atomic.Int64
+}
+
+func (c *CostlyPrimitive) Version() string { return "1.0.0" }
+func (c *CostlyPrimitive) Cost() uint64    { return c.cost }
+func (c *CostlyPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	c.calls.Add(1)
+	return map[string]interface{}{"valid": true}
+}
+
+// failureKind returns the failure kind recorded in a signal's metadata
+func failureKind(signal map[string]interface{}) interface{} {
+	return signal["metadata"].(map[string]interface{})["failure"]
+}
+
+func TestCostWithinBudgetIsRecorded(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithCostBudget(10))
+	engine.RegisterPrimitive("a", &CostlyPrimitive{cost: 3})
+	engine.RegisterPrimitive("b", &MockPrimitive{name: "b", version: "1.0.0", valid: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, uint64(10), decision.Proof.CostBudget)
+	assert.Equal(t, uint64(4), decision.Proof.CostConsumed)
+}
+
+func TestBudgetExhaustionFailsClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithCostBudget(5), core.WithDefaultMode(core.Exhaustive))
+	first := &CostlyPrimitive{cost: 3}
+	second := &CostlyPrimitive{cost: 3}
+	third := &CostlyPrimitive{cost: 1}
+	engine.RegisterPrimitive("first", first)
+	engine.RegisterPrimitive("second", second)
+	engine.RegisterPrimitive("third", third)
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Indeterminate, decision.Outcome)
+	assert.Len(t, decision.Signals, 2)
+	assert.Equal(t, string(core.FailureBudgetExhausted), failureKind(decision.Signals[1]))
+	assert.Equal(t, int64(0), second.calls.Load())
+	assert.Equal(t, int64(0), third.calls.Load())
+	assert.Equal(t, uint64(3), decision.Proof.CostConsumed)
+	assert.Equal(t, []string{"first", "second"}, decision.Proof.EvaluationOrder)
+}
+
+func TestPerCallBudgetOverridesEngineBudget(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithCostBudget(1))
+	engine.RegisterPrimitive("a", &CostlyPrimitive{cost: 3})
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	assert.False(t, engine.Evaluate(dctx).Permitted)
+	assert.True(t, engine.EvaluateContext(context.Background(), dctx, core.WithBudget(3)).Permitted)
+
+	unmetered := engine.EvaluateContext(context.Background(), dctx, core.WithBudget(0))
+	assert.True(t, unmetered.Permitted)
+	assert.Equal(t, uint64(0), unmetered.Proof.CostBudget)
+}
+
+func TestSequentialAndChargesEachChild(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	children := []*CostlyPrimitive{{cost: 1}, {cost: 1}, {cost: 1}}
+	composite := composer.SequentialAnd([]core.GovernancePrimitive{children[0], children[1], children[2]})
+
+	engine := core.NewGovernanceEngine(core.WithCostBudget(2))
+	engine.RegisterPrimitive("seq", composite)
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, string(core.FailureBudgetExhausted), failureKind(decision.Signals[0]))
+	assert.Equal(t, int64(1), children[1].calls.Load())
+	assert.Equal(t, int64(0), children[2].calls.Load())
+	assert.Equal(t, uint64(2), decision.Proof.CostConsumed)
+}
+
+func TestThresholdExhaustionFailsClosedEvenWhenMet(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	composite := composer.Threshold([]core.GovernancePrimitive{
+		&CostlyPrimitive{cost: 1}, &CostlyPrimitive{cost: 1}, &CostlyPrimitive{cost: 1},
+	}, 1)
+
+	engine := core.NewGovernanceEngine(core.WithCostBudget(2))
+	engine.RegisterPrimitive("threshold", composite)
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, string(core.FailureBudgetExhausted), failureKind(decision.Signals[0]))
+}
+
+func TestParallelAndReservesCostUpFront(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	children := []*CostlyPrimitive{{cost: 1}, {cost: 1}, {cost: 1}}
+	composite := composer.ParallelAnd([]core.GovernancePrimitive{children[0], children[1], children[2]})
+
+	engine := core.NewGovernanceEngine(core.WithCostBudget(2))
+	engine.RegisterPrimitive("par", composite)
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	for _, child := range children {
+		assert.Equal(t, int64(0), child.calls.Load())
+	}
+
+	permitted := engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{}, 0), core.WithBudget(3))
+	assert.True(t, permitted.Permitted)
+	assert.Equal(t, uint64(3), permitted.Proof.CostConsumed)
+}
+
+func TestShadowPrimitivesAreNotMetered(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithCostBudget(1))
+	engine.RegisterPrimitive("observer", &CostlyPrimitive{cost: 100}, core.AsShadow())
+	engine.RegisterPrimitive("gate", &CostlyPrimitive{cost: 1})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, uint64(1), decision.Proof.CostConsumed)
+}
+
+func TestEstimateCost(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("a", &CostlyPrimitive{cost: 5})
+	engine.RegisterPrimitive("b", &MockPrimitive{name: "b", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("c", composer.Threshold([]core.GovernancePrimitive{
+		&CostlyPrimitive{cost: 2},
+		composer.SequentialAnd([]core.GovernancePrimitive{&CostlyPrimitive{cost: 3}, &CostlyPrimitive{cost: 4}}),
+	}, 1))
+	engine.RegisterPrimitive("shadow", &CostlyPrimitive{cost: 50}, core.AsShadow())
+
+	assert.Equal(t, uint64(15), engine.EstimateCost())
+}
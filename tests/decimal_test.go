@@ -0,0 +1,75 @@
+/*
+Unit tests for exact decimals.
+*/
+
+package tests
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"0":      "0",
+		"12.50":  "12.50",
+		"-0.001": "-0.001",
+		"+7":     "7",
+		"1.5e3":  "1500",
+		"25E-4":  "0.0025",
+		".5":     "0.5",
+		"123456789012345678901234567890.123456789": "123456789012345678901234567890.123456789",
+	}
+	for input, want := range cases {
+		d, err := core.ParseDecimal(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, d.String(), input)
+	}
+
+	for _, input := range []string{"", "-", "1.2.3", "abc", "1e", "1e3x", "1e99999", "0x10"} {
+		_, err := core.ParseDecimal(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestDecimalCompare(t *testing.T) {
+	a, _ := core.ParseDecimal("1.50")
+	b, _ := core.ParseDecimal("1.5")
+	c, _ := core.ParseDecimal("1.500001")
+
+	assert.True(t, a.Equal(b))
+	assert.Equal(t, -1, a.Cmp(c))
+	assert.Equal(t, 1, c.Cmp(b))
+	assert.Equal(t, 0, core.Decimal{}.Cmp(core.NewDecimal(0, 4)))
+	assert.Equal(t, -1, core.NewDecimal(-5, 1).Sign())
+}
+
+func TestDecimalAccessorsCopy(t *testing.T) {
+	unscaled := big.NewInt(1234)
+	d := core.NewDecimalFromBigInt(unscaled, 2)
+	unscaled.SetInt64(0)
+	d.Unscaled().SetInt64(0)
+
+	assert.Equal(t, "12.34", d.String())
+	assert.Equal(t, int32(2), d.Scale())
+	assert.Equal(t, "120", core.NewDecimal(12, -1).String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	d, _ := core.ParseDecimal("9007199254740993.01")
+	data, err := json.Marshal(map[string]interface{}{"amount": d})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":9007199254740993.01}`, string(data))
+
+	var decoded struct {
+		Number core.Decimal `json:"number"`
+		Text   core.Decimal `json:"text"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"number":0.10,"text":"-3.5"}`), &decoded))
+	assert.Equal(t, "0.10", decoded.Number.String())
+	assert.Equal(t, "-3.5", decoded.Text.String())
+}
@@ -0,0 +1,131 @@
+/*
+Unit tests for the decision cache.
+*/
+
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// CountingPrimitive counts its evaluations and permits
+type CountingPrimitive struct {
+	version string
+	calls   atomic.Int64
+}
+
+func (c *CountingPrimitive) Version() string { return c.version }
+func (c *CountingPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	c.calls.Add(1)
+	return map[string]interface{}{"valid": true, "evidence": []interface{}{"counted"}}
+}
+
+func TestDecisionCacheHit(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(16, 0))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+
+	first := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"a": 1}, 5))
+	second := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"a": 1}, 5))
+
+	assert.Equal(t, int64(1), counter.calls.Load())
+	assert.False(t, first.Proof.CacheHit)
+	assert.True(t, second.Proof.CacheHit)
+	assert.Equal(t, first.Permitted, second.Permitted)
+	assert.Equal(t, first.Proof.SignalCommitments, second.Proof.SignalCommitments)
+	assert.Equal(t, first.Proof.GeneratedAt, second.Proof.GeneratedAt)
+	assert.Equal(t, 1, engine.CachedDecisions())
+}
+
+func TestDecisionCacheKeyedByContextAndTime(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(16, 0))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"a": 1}, 5))
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"a": 2}, 5))
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"a": 1}, 6))
+	engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 1}, 5), core.WithMode(core.Exhaustive))
+
+	assert.Equal(t, int64(4), counter.calls.Load())
+}
+
+func TestDecisionCacheInvalidatedByRegistryChange(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(16, 0))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 5)
+
+	engine.Evaluate(dctx)
+	engine.ReplacePrimitive("counter", &CountingPrimitive{version: "2.0.0"})
+	assert.Equal(t, 0, engine.CachedDecisions())
+
+	decision := engine.Evaluate(dctx)
+	assert.False(t, decision.Proof.CacheHit)
+	assert.Equal(t, "2.0.0", decision.Proof.PrimitiveVersions["counter"])
+
+	engine.InvalidateCache()
+	assert.Equal(t, 0, engine.CachedDecisions())
+}
+
+func TestDecisionCacheEntryBound(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(2, 0))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+
+	for i := 0; i < 3; i++ {
+		engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"i": i}, 0))
+	}
+	assert.Equal(t, 2, engine.CachedDecisions())
+
+	// The oldest entry was evicted, the newest is still cached
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"i": 0}, 0))
+	assert.Equal(t, int64(4), counter.calls.Load())
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"i": 2}, 0))
+	assert.Equal(t, int64(4), counter.calls.Load())
+}
+
+func TestDecisionCacheByteBound(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(0, 1))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	engine.Evaluate(dctx)
+	engine.Evaluate(dctx)
+
+	assert.Equal(t, int64(2), counter.calls.Load())
+	assert.Equal(t, 0, engine.CachedDecisions())
+}
+
+func TestDecisionCacheSkipsTimeouts(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(16, 0))
+	blocking := NewBlockingPrimitive("1.0.0")
+	defer blocking.Release()
+	engine.RegisterPrimitive("slow", blocking, core.WithTimeout(10*time.Millisecond))
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, 0, engine.CachedDecisions())
+}
+
+func TestDecisionCacheReturnsCopies(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionCache(16, 0))
+	engine.RegisterPrimitive("counter", &CountingPrimitive{version: "1.0.0"})
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	first := engine.Evaluate(dctx)
+	first.Permitted = false
+	first.Signals[0]["valid"] = false
+
+	second := engine.Evaluate(dctx)
+	assert.True(t, second.Permitted)
+	assert.Equal(t, true, second.Signals[0]["valid"])
+}
@@ -0,0 +1,140 @@
+/*
+Unit tests for decision validity windows.
+*/
+
+package tests
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// WindowPrimitive permits with an explicit validity window
+type WindowPrimitive struct {
+	from, until interface{}
+}
+
+func (w *WindowPrimitive) Version() string { return "1.0.0" }
+func (w *WindowPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	result := map[string]interface{}{"valid": true}
+	if w.from != nil {
+		result["valid_from"] = w.from
+	}
+	if w.until != nil {
+		result["valid_until"] = w.until
+	}
+	return result
+}
+
+func TestDecisionWithoutWindowIsUnbounded(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 10))
+
+	assert.Equal(t, core.UnboundedValidity, decision.Validity)
+	assert.Nil(t, decision.Proof.Validity)
+	assert.NoError(t, decision.EnforceAt(math.MaxInt64-1))
+}
+
+func TestDecisionWindowIsIntersection(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("ttl", &MockPrimitive{name: "ttl", version: "1.0.0", valid: true}, core.WithValidity(100))
+	engine.RegisterPrimitive("window", &WindowPrimitive{from: 20, until: float64(500)})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 10))
+
+	expected := core.ValidityWindow{From: 20, Until: 110}
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, expected, decision.Validity)
+	assert.Equal(t, &expected, decision.Proof.Validity)
+	assert.Equal(t, core.ValidityWindow{From: 10, Until: 110}, decision.Signals[0]["validity"])
+}
+
+func TestEnforceAtRejectsStaleDecisions(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("ttl", &MockPrimitive{name: "ttl", version: "1.0.0", valid: true}, core.WithValidity(5))
+	engine.RegisterPrimitive("window", &WindowPrimitive{from: 12})
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 10))
+
+	var validityErr *core.ValidityError
+	err := decision.EnforceAt(11)
+	assert.True(t, errors.As(err, &validityErr))
+	assert.False(t, validityErr.Expired())
+
+	assert.NoError(t, decision.EnforceAt(12))
+	assert.NoError(t, decision.EnforceAt(14))
+
+	err = decision.EnforceAt(15)
+	assert.True(t, errors.As(err, &validityErr))
+	assert.True(t, validityErr.Expired())
+}
+
+func TestEnforceAtRequiresPermitAndObligations(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("deny", &MockPrimitive{name: "deny", version: "1.0.0", valid: false})
+	denied := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.Error(t, denied.EnforceAt(0))
+
+	var nilDecision *core.GovernanceDecision
+	assert.Error(t, nilDecision.CheckValidity(0))
+}
+
+func TestShadowPrimitiveWindowDoesNotNarrowDecision(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("observer", &WindowPrimitive{until: 1}, core.AsShadow())
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.Equal(t, core.UnboundedValidity, decision.Validity)
+}
+
+func TestMalformedValidityFailsClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("window", &WindowPrimitive{until: "tomorrow"})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, string(core.FailureMalformedResult), failureKind(decision.Signals[0]))
+
+	// With both bounds malformed, valid_from is always the one reported
+	engine = core.NewGovernanceEngine()
+	engine.RegisterPrimitive("window", &WindowPrimitive{from: 1.5, until: "tomorrow"})
+	for i := 0; i < 20; i++ {
+		decision = engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+		assert.Contains(t, decision.FailureReasons[0], "valid_from must be an integer logical time")
+	}
+}
+
+func TestCompositeIntersectsChildWindows(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+	composite := composer.SequentialAnd([]core.GovernancePrimitive{
+		&WindowPrimitive{from: 5, until: 50},
+		&WindowPrimitive{until: 30},
+	})
+
+	result := composite.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.Equal(t, int64(5), result["valid_from"])
+	assert.Equal(t, int64(30), result["valid_until"])
+
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("composite", composite)
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.Equal(t, core.ValidityWindow{From: 5, Until: 30}, decision.Validity)
+}
+
+func TestValidityTTLIsPartOfRegistryHash(t *testing.T) {
+	plain := core.NewGovernanceEngine()
+	plain.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	bounded := core.NewGovernanceEngine()
+	bounded.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true}, core.WithValidity(10))
+
+	assert.NotEqual(t, plain.RegistryHash(), bounded.RegistryHash())
+}
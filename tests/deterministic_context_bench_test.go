@@ -0,0 +1,184 @@
+/*
+Benchmarks comparing the persistent DeterministicContext against the
+previous JSON deep-copy implementation.
+*/
+
+package tests
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"gsas/core"
+)
+
+// legacyDeepCopy is the JSON round-trip copy the context used to make on
+// construction and on every call to Data
+func legacyDeepCopy(d map[string]interface{}) map[string]interface{} {
+	bytes, err := json.Marshal(d)
+	if err != nil {
+		return make(map[string]interface{})
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return make(map[string]interface{})
+	}
+	return result
+}
+
+// legacyDeepCopyValue is the recursive copy the context used to return from Get
+func legacyDeepCopyValue(d interface{}) interface{} {
+	switch v := d.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for k, val := range v {
+			result[k] = legacyDeepCopyValue(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = legacyDeepCopyValue(val)
+		}
+		return result
+	default:
+		return d
+	}
+}
+
+// legacyContext is the mutex-guarded map the context used to be
+type legacyContext struct {
+	data map[string]interface{}
+	mu   sync.RWMutex
+}
+
+func newLegacyContext(data map[string]interface{}) *legacyContext {
+	return &legacyContext{data: legacyDeepCopy(data)}
+}
+
+func (lc *legacyContext) Get(key string, defaultValue interface{}) interface{} {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	if val, exists := lc.data[key]; exists {
+		return legacyDeepCopyValue(val)
+	}
+	return defaultValue
+}
+
+func (lc *legacyContext) Data() map[string]interface{} {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return legacyDeepCopy(lc.data)
+}
+
+const benchmarkContextKeys = 2000
+
+func BenchmarkContextConstruction(b *testing.B) {
+	data := largeContextData(benchmarkContextKeys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		core.NewDeterministicContext(data, 0)
+	}
+}
+
+func BenchmarkLegacyContextConstruction(b *testing.B) {
+	data := largeContextData(benchmarkContextKeys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newLegacyContext(data)
+	}
+}
+
+func BenchmarkContextGet(b *testing.B) {
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Get("key-1234", nil)
+	}
+}
+
+func BenchmarkLegacyContextGet(b *testing.B) {
+	ctx := newLegacyContext(largeContextData(benchmarkContextKeys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Get("key-1234", nil)
+	}
+}
+
+// Reading one field of a nested value: a view or a typed query reads it in
+// place, where the legacy Get deep-copied the whole value first
+
+func BenchmarkContextView(b *testing.B) {
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entity, _ := ctx.View("key-1234")
+		items, _ := entity.Field("items")
+		item, _ := items.Index(39)
+		item.AsFloat()
+	}
+}
+
+func BenchmarkContextQuery(b *testing.B) {
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.QueryFloat("key-1234.items[39]")
+	}
+}
+
+func BenchmarkLegacyContextNestedRead(b *testing.B) {
+	ctx := newLegacyContext(largeContextData(benchmarkContextKeys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entity := ctx.Get("key-1234", nil).(map[string]interface{})
+		_ = entity["items"].([]interface{})[39].(float64)
+	}
+}
+
+func BenchmarkContextData(b *testing.B) {
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Data()
+	}
+}
+
+func BenchmarkLegacyContextData(b *testing.B) {
+	ctx := newLegacyContext(largeContextData(benchmarkContextKeys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Data()
+	}
+}
+
+// Derivation is only reachable through interceptor enrichment, so both
+// derivation benchmarks time the same evaluation and differ only in how the
+// enriched context is derived
+
+func BenchmarkContextDerivation(b *testing.B) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.AddInterceptor(&RecordingInterceptor{name: "enrich", calls: &[]string{}, enrich: map[string]interface{}{"risk": "low"}})
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.Evaluate(ctx)
+	}
+}
+
+func BenchmarkLegacyContextDerivation(b *testing.B) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	ctx := core.NewDeterministicContext(largeContextData(benchmarkContextKeys), 0)
+	legacy := newLegacyContext(largeContextData(benchmarkContextKeys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Enrichment used to copy the data out, merge, and copy it into a new context
+		enriched := legacy.Data()
+		enriched["risk"] = "low"
+		newLegacyContext(enriched)
+		engine.Evaluate(ctx)
+	}
+}
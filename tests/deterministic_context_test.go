@@ -0,0 +1,134 @@
+/*
+Unit tests for the persistent DeterministicContext.
+*/
+
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// largeContextData builds n keys holding nested maps and lists
+func largeContextData(n int) map[string]interface{} {
+	data := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		items := make([]interface{}, 40)
+		for j := range items {
+			items[j] = float64(i*j) + 0.5
+		}
+		data[fmt.Sprintf("key-%d", i)] = map[string]interface{}{
+			"id":    float64(i),
+			"name":  fmt.Sprintf("entity-%d", i),
+			"items": items,
+		}
+	}
+	return data
+}
+
+func TestLargeContextRoundTrips(t *testing.T) {
+	data := largeContextData(5000)
+	ctx := core.NewDeterministicContext(data, 1)
+
+	assert.Equal(t, 5000, ctx.Len())
+	assert.Equal(t, data, ctx.Data())
+	for _, i := range []int{0, 1, 777, 4999} {
+		key := fmt.Sprintf("key-%d", i)
+		assert.True(t, ctx.Has(key))
+		assert.Equal(t, data[key], ctx.Get(key, nil))
+	}
+	assert.False(t, ctx.Has("key-5000"))
+}
+
+func TestContextHandlesHashCollisions(t *testing.T) {
+	// These keys share a 32-bit FNV-1a hash
+	ctx := core.NewDeterministicContext(map[string]interface{}{
+		"key-901258":  "first",
+		"key-1540052": "second",
+		"other":       "third",
+	}, 0)
+
+	assert.Equal(t, "first", ctx.Get("key-901258", nil))
+	assert.Equal(t, "second", ctx.Get("key-1540052", nil))
+	assert.Equal(t, []string{"key-1540052", "key-901258", "other"}, ctx.Keys())
+}
+
+func TestContextNormalisesValues(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+	ctx := core.NewDeterministicContext(map[string]interface{}{
+		"int":    7,
+		"uint":   uint8(3),
+		"float":  float32(1.5),
+		"list":   []string{"a", "b"},
+		"struct": point{X: 2},
+	}, 0)
+
+	assert.Equal(t, int64(7), ctx.Get("int", nil))
+	assert.Equal(t, uint64(3), ctx.Get("uint", nil))
+	assert.Equal(t, 1.5, ctx.Get("float", nil))
+	assert.Equal(t, []interface{}{"a", "b"}, ctx.Get("list", nil))
+	assert.Equal(t, map[string]interface{}{"x": int64(2)}, ctx.Get("struct", nil))
+}
+
+func TestContextReturnsIndependentCopies(t *testing.T) {
+	ctx := core.NewDeterministicContext(map[string]interface{}{
+		"nested": map[string]interface{}{"list": []interface{}{1, 2}},
+	}, 0)
+
+	copied := ctx.Get("nested", nil).(map[string]interface{})
+	copied["list"].([]interface{})[0] = "changed"
+	copied["extra"] = true
+	data := ctx.Data()
+	data["nested"] = nil
+
+	assert.Equal(t, map[string]interface{}{"list": []interface{}{int64(1), int64(2)}}, ctx.Get("nested", nil))
+}
+
+func TestContextHashIgnoresConstructionOrder(t *testing.T) {
+	first := core.NewDeterministicContext(largeContextData(200), 3)
+	second := core.NewDeterministicContext(largeContextData(200), 3)
+
+	firstHash, err := first.Hash()
+	assert.NoError(t, err)
+	secondHash, err := second.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, firstHash, secondHash)
+}
+
+func TestContextConcurrentReads(t *testing.T) {
+	ctx := core.NewDeterministicContext(largeContextData(500), 0)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key-%d", (i+w)%500)
+				assert.True(t, ctx.Has(key))
+				ctx.Get(key, nil)
+			}
+			ctx.Data()
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestEnrichmentSharesLargeContext(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.AddInterceptor(&RecordingInterceptor{name: "enrich", calls: &[]string{}, enrich: map[string]interface{}{"risk": "low"}})
+	engine.RegisterPrimitive("risk", &ContextReadingPrimitive{key: "risk"})
+	engine.RegisterPrimitive("original", &ContextReadingPrimitive{key: "key-4999"})
+
+	ctx := core.NewDeterministicContext(largeContextData(5000), 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.False(t, ctx.Has("risk"))
+}
@@ -0,0 +1,194 @@
+/*
+Unit tests for evaluation interceptors.
+*/
+
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// RecordingInterceptor records every hook call and optionally tampers with what it sees
+type RecordingInterceptor struct {
+	name   string
+	calls  *[]string
+	enrich map[string]interface{}
+	veto   error
+	tamper bool
+}
+
+func (r *RecordingInterceptor) BeforeEvaluation(ctx *core.DeterministicContext) (map[string]interface{}, error) {
+	*r.calls = append(*r.calls, r.name+":before_evaluation")
+	return r.enrich, r.veto
+}
+
+func (r *RecordingInterceptor) BeforePrimitive(id string, ctx *core.DeterministicContext) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%s:before:%s", r.name, id))
+}
+
+func (r *RecordingInterceptor) AfterPrimitive(id string, signal map[string]interface{}) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%s:after:%s", r.name, id))
+	if r.tamper {
+		signal["valid"] = true
+		signal["outcome"] = core.Permit
+		if obligations, ok := signal["obligations"].([]core.Obligation); ok {
+			for _, o := range obligations {
+				o.Attributes["field"] = "tampered"
+			}
+		}
+	}
+}
+
+func (r *RecordingInterceptor) AfterDecision(decision *core.GovernanceDecision) {
+	*r.calls = append(*r.calls, r.name+":after_decision")
+	if r.tamper {
+		decision.Permitted = true
+		decision.Outcome = core.Permit
+		decision.FailureReasons = nil
+		decision.Proof.Decision = true
+	}
+}
+
+// ContextReadingPrimitive permits only when key is present in the context
+type ContextReadingPrimitive struct {
+	key string
+}
+
+func (c *ContextReadingPrimitive) Version() string { return "1.0.0" }
+func (c *ContextReadingPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	return map[string]interface{}{"valid": ctx.(*core.DeterministicContext).Has(c.key)}
+}
+
+// PanickingObserver panics in every observation hook
+type PanickingObserver struct {
+	core.BaseInterceptor
+}
+
+func (PanickingObserver) AfterPrimitive(id string, signal map[string]interface{}) { panic("observer") }
+func (PanickingObserver) AfterDecision(decision *core.GovernanceDecision)         { panic("observer") }
+
+func TestInterceptorsRunInOrder(t *testing.T) {
+	calls := []string{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	assert.NoError(t, engine.AddInterceptor(&RecordingInterceptor{name: "audit", calls: &calls}))
+	assert.NoError(t, engine.AddInterceptor(&RecordingInterceptor{name: "metrics", calls: &calls}))
+
+	engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.Equal(t, []string{
+		"audit:before_evaluation",
+		"metrics:before_evaluation",
+		"audit:before:auth",
+		"metrics:before:auth",
+		"audit:after:auth",
+		"metrics:after:auth",
+		"audit:after_decision",
+		"metrics:after_decision",
+	}, calls)
+}
+
+func TestInterceptorEnrichesContext(t *testing.T) {
+	calls := []string{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("risk", &ContextReadingPrimitive{key: "risk_score"})
+	engine.AddInterceptor(&RecordingInterceptor{
+		name:   "enrich",
+		calls:  &calls,
+		enrich: map[string]interface{}{"risk_score": 3},
+	})
+
+	original := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(original)
+
+	assert.True(t, decision.Permitted)
+	assert.False(t, original.Has("risk_score"))
+}
+
+func TestInterceptorCannotOverwriteContext(t *testing.T) {
+	calls := []string{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.AddInterceptor(&RecordingInterceptor{
+		name:   "enrich",
+		calls:  &calls,
+		enrich: map[string]interface{}{"user": "admin"},
+	})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"user": "guest"}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "cannot overwrite context key 'user'")
+	assert.Empty(t, decision.Signals)
+}
+
+func TestInterceptorVetoDenies(t *testing.T) {
+	calls := []string{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.AddInterceptor(&RecordingInterceptor{name: "kill_switch", calls: &calls, veto: errors.New("kill switch engaged")})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "kill switch engaged")
+	assert.False(t, decision.Proof.Decision)
+	assert.Equal(t, []string{"kill_switch:before_evaluation", "kill_switch:after_decision"}, calls)
+}
+
+func TestInterceptorCannotTurnDenialIntoPermit(t *testing.T) {
+	calls := []string{}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("denied", &MockPrimitive{name: "denied", version: "1.0.0", valid: false})
+	engine.AddInterceptor(&RecordingInterceptor{name: "tamper", calls: &calls, tamper: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Deny, decision.Outcome)
+	assert.Len(t, decision.FailureReasons, 1)
+	assert.False(t, decision.Proof.Decision)
+	assert.Equal(t, false, decision.Signals[0]["valid"])
+}
+
+func TestInterceptorCannotMutateObligations(t *testing.T) {
+	calls := []string{}
+	attributes := map[string]interface{}{"field": "ssn"}
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("privacy", &ConditionalPrimitive{
+		name:        "privacy",
+		valid:       true,
+		obligations: []core.Obligation{{ID: "redact_field", Attributes: attributes}},
+	})
+	engine.AddInterceptor(&RecordingInterceptor{name: "tamper", calls: &calls, tamper: true})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, "ssn", decision.Obligations[0].Attributes["field"])
+	pg := &core.ProofGenerator{}
+	assert.Equal(t, pg.CommitObligation(decision.Obligations[0]), decision.Proof.ObligationCommitments[0])
+
+	// The decision does not alias the attributes the primitive returned
+	attributes["field"] = "changed"
+	assert.Equal(t, "ssn", decision.Obligations[0].Attributes["field"])
+}
+
+func TestPanickingObserverIsContained(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.AddInterceptor(PanickingObserver{})
+
+	var decision *core.GovernanceDecision
+	assert.NotPanics(t, func() {
+		decision = engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	})
+	assert.True(t, decision.Permitted)
+
+	assert.Error(t, engine.AddInterceptor(nil))
+}
@@ -0,0 +1,132 @@
+/*
+Unit tests for the pre-execution gate.
+*/
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// recordingAction counts how often it runs and returns result and err
+func recordingAction(calls *int, result interface{}, err error) core.Action {
+	return func(ctx context.Context) (interface{}, error) {
+		*calls++
+		return result, err
+	}
+}
+
+func TestGateExecutesOnPermit(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(1)))
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	gate := core.NewGate(engine, core.WithReceiptClock(core.NewLogicalClock(100)))
+	dctx := core.NewDeterministicContext(map[string]interface{}{"amount": 10}, 0)
+
+	calls := 0
+	result, receipt, err := gate.Execute(context.Background(), dctx, recordingAction(&calls, "transferred", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "transferred", result)
+	assert.Equal(t, 1, calls)
+	assert.True(t, receipt.Permitted)
+	assert.True(t, receipt.Executed)
+	assert.Len(t, receipt.OutcomeHash, 64)
+	assert.Equal(t, int64(100), receipt.StartedAt)
+	assert.Equal(t, int64(101), receipt.CompletedAt)
+
+	// The receipt is bound to the proof of the decision that permitted it
+	expected := core.NewGovernanceEngine(core.WithClock(core.NewLogicalClock(1)))
+	expected.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	assert.Equal(t, expected.Evaluate(dctx).Proof.CommitmentHash(), receipt.ProofHash)
+}
+
+func TestGateRefusesDenial(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("deny", &MockPrimitive{name: "deny", version: "1.0.0", valid: false})
+	gate := core.NewGate(engine)
+
+	calls := 0
+	result, receipt, err := gate.Execute(context.Background(), core.NewDeterministicContext(map[string]interface{}{}, 0), recordingAction(&calls, "x", nil))
+
+	var refused *core.GateRefusedError
+	assert.True(t, errors.As(err, &refused))
+	assert.False(t, refused.Decision.Permitted)
+	assert.Nil(t, result)
+	assert.Equal(t, 0, calls)
+	assert.False(t, receipt.Permitted)
+	assert.False(t, receipt.Executed)
+	assert.NotEmpty(t, receipt.ProofHash)
+	assert.NotEmpty(t, receipt.Error)
+}
+
+func TestGateRequiresAcknowledgedObligations(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("regulator", &ConditionalPrimitive{
+		name: "regulator", valid: true, obligations: []string{"log_to_regulator_feed"},
+	})
+	gate := core.NewGate(engine)
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	calls := 0
+	_, _, err := gate.Execute(context.Background(), dctx, recordingAction(&calls, nil, nil))
+	var unfulfilled *core.UnfulfilledObligationsError
+	assert.True(t, errors.As(err, &unfulfilled))
+	assert.Equal(t, 0, calls)
+
+	_, receipt, err := gate.Execute(context.Background(), dctx, recordingAction(&calls, nil, nil), "log_to_regulator_feed")
+	assert.NoError(t, err)
+	assert.True(t, receipt.Executed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestGateRefusesOutsideValidity(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("window", &WindowPrimitive{from: 50})
+	gate := core.NewGate(engine)
+
+	calls := 0
+	_, _, err := gate.Execute(context.Background(), core.NewDeterministicContext(map[string]interface{}{}, 10), recordingAction(&calls, nil, nil))
+
+	var validityErr *core.ValidityError
+	assert.True(t, errors.As(err, &validityErr))
+	assert.Equal(t, 0, calls)
+}
+
+func TestGateRecordsActionFailure(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	gate := core.NewGate(engine)
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	calls := 0
+	actionErr := errors.New("downstream unavailable")
+	_, receipt, err := gate.Execute(context.Background(), dctx, recordingAction(&calls, nil, actionErr))
+	assert.Equal(t, actionErr, err)
+	assert.True(t, receipt.Executed)
+	assert.Empty(t, receipt.OutcomeHash)
+	assert.Equal(t, "downstream unavailable", receipt.Error)
+
+	_, receipt, err = gate.Execute(context.Background(), dctx, func(ctx context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	assert.Error(t, err)
+	assert.True(t, receipt.Executed)
+	assert.Contains(t, receipt.Error, "boom")
+}
+
+func TestGatePassesEvaluationOptions(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("costly", &CostlyPrimitive{cost: 5})
+	gate := core.NewGate(engine, core.WithGateEvaluation(core.WithBudget(1)))
+
+	calls := 0
+	_, _, err := gate.Execute(context.Background(), core.NewDeterministicContext(map[string]interface{}{}, 0), recordingAction(&calls, nil, nil))
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, calls)
+}
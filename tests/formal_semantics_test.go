@@ -90,5 +90,5 @@ func TestDeterministicContextGetItem(t *testing.T) {
 
 	val, err := ctx.GetItem("x")
 	assert.NoError(t, err)
-	assert.Equal(t, float64(1), val) // JSON unmarshaling converts to float64
+	assert.Equal(t, int64(1), val) // Integers keep their type
 }
\ No newline at end of file
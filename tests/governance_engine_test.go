@@ -5,7 +5,9 @@ Unit tests for governance evaluation engine.
 package tests
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gsas/core"
@@ -98,4 +100,190 @@ func TestGovernanceEngineClear(t *testing.T) {
 
 	engine.Clear()
 	assert.Equal(t, 0, engine.PrimitiveCount())
-}
\ No newline at end of file
+}
+
+func TestGovernanceEnginePrimitiveTimeout(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithPrimitiveTimeout(20 * time.Millisecond))
+	slow := NewBlockingPrimitive("1.0.0")
+	defer slow.Release()
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("slow", slow)
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.Signals, 2)
+	assert.Len(t, decision.FailureReasons, 1)
+	assert.Contains(t, decision.FailureReasons[0], "timed out")
+	meta := decision.Signals[1]["metadata"].(map[string]interface{})
+	assert.Equal(t, string(core.FailureTimeout), meta["failure"])
+	assert.Len(t, decision.Proof.SignalCommitments, 2)
+	assert.Equal(t, []string{"auth", "slow"}, decision.Proof.EvaluationOrder)
+}
+
+func TestGovernanceEnginePerPrimitiveTimeout(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	slow := NewBlockingPrimitive("1.0.0")
+	defer slow.Release()
+
+	engine.RegisterPrimitive("slow", slow, core.WithTimeout(10*time.Millisecond))
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "timed out after 10ms")
+}
+
+func TestGovernanceEngineDecisionTimeout(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDecisionTimeout(20 * time.Millisecond))
+	slow := NewBlockingPrimitive("1.0.0")
+	defer slow.Release()
+
+	engine.RegisterPrimitive("slow", slow)
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Contains(t, decision.FailureReasons[0], "decision deadline exceeded")
+}
+
+func TestGovernanceEngineCancellation(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	goCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.EvaluateContext(goCtx, ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.Signals, 1)
+	meta := decision.Signals[0]["metadata"].(map[string]interface{})
+	assert.Equal(t, string(core.FailureCancelled), meta["failure"])
+	assert.False(t, decision.Proof.Decision)
+}
+
+func TestGovernanceEnginePanicIsolation(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("buggy", &PanickingPrimitive{name: "buggy", version: "3.1.0", value: "nil map write"})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	var decision *core.GovernanceDecision
+	assert.NotPanics(t, func() { decision = engine.Evaluate(ctx) })
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.Signals, 2)
+	assert.Contains(t, decision.FailureReasons[0], "panicked: nil map write")
+
+	meta := decision.Signals[1]["metadata"].(map[string]interface{})
+	assert.Equal(t, string(core.FailurePanic), meta["failure"])
+	assert.Equal(t, "buggy", meta["primitive_id"])
+	assert.Equal(t, "3.1.0", meta["version"])
+	assert.Equal(t, "nil map write", meta["panic"])
+	assert.Len(t, decision.Proof.SignalCommitments, 2)
+}
+
+func TestGovernanceEnginePanicWithTimeout(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithPrimitiveTimeout(time.Second))
+	engine.RegisterPrimitive("buggy", &PanickingPrimitive{name: "buggy", version: "1.0.0", value: 42})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	meta := decision.Signals[0]["metadata"].(map[string]interface{})
+	assert.Equal(t, "42", meta["panic"])
+}
+
+func TestGovernanceEngineExhaustiveMode(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDefaultMode(core.Exhaustive))
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: false})
+	engine.RegisterPrimitive("rate_limit", &MockPrimitive{name: "rate_limit", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("jurisdiction", &MockPrimitive{name: "jurisdiction", version: "1.0.0", valid: false})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.Signals, 3)
+	assert.Len(t, decision.FailureReasons, 2)
+	assert.Contains(t, decision.FailureReasons[0], "auth")
+	assert.Contains(t, decision.FailureReasons[1], "jurisdiction")
+	assert.Equal(t, []string{"auth", "rate_limit", "jurisdiction"}, decision.Proof.EvaluationOrder)
+	assert.Len(t, decision.Proof.SignalCommitments, 3)
+	assert.False(t, decision.Proof.Decision)
+}
+
+func TestGovernanceEngineModePerCall(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("a", &MockPrimitive{name: "a", version: "1.0.0", valid: false})
+	engine.RegisterPrimitive("b", &MockPrimitive{name: "b", version: "1.0.0", valid: false})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	failFast := engine.Evaluate(ctx)
+	assert.Len(t, failFast.FailureReasons, 1)
+
+	exhaustive := engine.EvaluateContext(context.Background(), ctx, core.WithMode(core.Exhaustive))
+	assert.Len(t, exhaustive.FailureReasons, 2)
+	assert.Equal(t, []string{"a", "b"}, exhaustive.Proof.EvaluationOrder)
+}
+
+func TestGovernanceEngineShadowPrimitiveNeverGates(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("new_rule", &MockPrimitive{name: "new_rule", version: "0.1.0", valid: false}, core.AsShadow())
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.Empty(t, decision.FailureReasons)
+	assert.Len(t, decision.ShadowFailures, 1)
+	assert.Contains(t, decision.ShadowFailures[0], "new_rule")
+	assert.Len(t, decision.Signals, 2)
+	assert.Equal(t, true, decision.Signals[1]["shadow"])
+	assert.Equal(t, []string{"auth", "new_rule"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, []string{"new_rule"}, decision.Proof.ShadowPrimitives)
+	assert.Len(t, decision.Proof.SignalCommitments, 2)
+}
+
+func TestGovernanceEngineShadowEvaluatedAfterDenial(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("denied", &MockPrimitive{name: "denied", version: "1.0.0", valid: false})
+	engine.RegisterPrimitive("skipped", &MockPrimitive{name: "skipped", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("shadow", &PanickingPrimitive{name: "shadow", version: "0.1.0", value: "boom"}, core.AsShadow())
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.FailureReasons, 1)
+	assert.Len(t, decision.ShadowFailures, 1)
+	assert.Equal(t, []string{"denied", "shadow"}, decision.Proof.EvaluationOrder)
+}
+
+func TestShadowPrimitiveWithNilContext(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("new_rule", &MockPrimitive{name: "new_rule", version: "0.1.0", valid: true}, core.AsShadow())
+
+	var decision *core.GovernanceDecision
+	assert.NotPanics(t, func() {
+		decision = engine.Evaluate(nil)
+	})
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"auth", "new_rule"}, decision.Proof.EvaluationOrder)
+}
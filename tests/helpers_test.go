@@ -4,7 +4,11 @@ Shared test helpers and fixtures for GSAS tests.
 
 package tests
 
-import "gsas/core"
+import (
+	"sync"
+
+	"gsas/core"
+)
 
 // MockPrimitive is a test double for GovernancePrimitive
 type MockPrimitive struct {
@@ -25,4 +29,63 @@ func (m *MockPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
 
 // Ensure MockPrimitive implements interfaces
 var _ core.GovernancePrimitive = (*MockPrimitive)(nil)
-var _ core.NamedPrimitive = (*MockPrimitive)(nil)
\ No newline at end of file
+var _ core.NamedPrimitive = (*MockPrimitive)(nil)
+
+// BlockingPrimitive blocks in Evaluate until release is closed
+type BlockingPrimitive struct {
+	version string
+	release chan struct{}
+	started chan struct{}
+	once    sync.Once
+}
+
+func NewBlockingPrimitive(version string) *BlockingPrimitive {
+	return &BlockingPrimitive{
+		version: version,
+		release: make(chan struct{}),
+		started: make(chan struct{}),
+	}
+}
+
+func (b *BlockingPrimitive) Version() string { return b.version }
+func (b *BlockingPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return map[string]interface{}{"valid": true}
+}
+
+// Started is closed once Evaluate has been entered
+func (b *BlockingPrimitive) Started() <-chan struct{} { return b.started }
+
+// Release unblocks every pending and future Evaluate call
+func (b *BlockingPrimitive) Release() { close(b.release) }
+
+// PanickingPrimitive panics with value in Evaluate
+type PanickingPrimitive struct {
+	name    string
+	version string
+	value   interface{}
+}
+
+func (p *PanickingPrimitive) Name() string    { return p.name }
+func (p *PanickingPrimitive) Version() string { return p.version }
+func (p *PanickingPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	panic(p.value)
+}
+
+// OutcomePrimitive returns a fixed four-state outcome
+type OutcomePrimitive struct {
+	name    string
+	version string
+	outcome core.Outcome
+}
+
+func (o *OutcomePrimitive) Name() string    { return o.name }
+func (o *OutcomePrimitive) Version() string { return o.version }
+func (o *OutcomePrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"outcome":  o.outcome,
+		"metadata": map[string]interface{}{"primitive": o.name},
+		"evidence": []interface{}{},
+	}
+}
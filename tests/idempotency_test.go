@@ -0,0 +1,169 @@
+/*
+Unit tests for idempotent evaluation.
+*/
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// ManualClock is a wall clock that only moves when advanced
+type ManualClock struct {
+	now atomic.Int64
+}
+
+func (c *ManualClock) Now() int64              { return c.now.Load() }
+func (c *ManualClock) Kind() core.ClockKind    { return core.ClockWall }
+func (c *ManualClock) Advance(d time.Duration) { c.now.Add(int64(d)) }
+
+func TestIdempotentEvaluationReturnsOriginalDecision(t *testing.T) {
+	// Every proof gets a new timestamp, so a re-evaluation would be told apart
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 0), core.WithClock(core.NewLogicalClock(0)))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	first := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	retry := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+
+	assert.Equal(t, int64(1), counter.calls.Load())
+	assert.Equal(t, first, retry)
+	assert.Equal(t, first.Proof.GeneratedAt, retry.Proof.GeneratedAt)
+	assert.NotSame(t, first, retry)
+
+	// A different request ID is a new decision
+	engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-2"))
+	assert.Equal(t, int64(2), counter.calls.Load())
+}
+
+func TestIdempotentEvaluationSurvivesRegistryChanges(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 0), core.WithClock(core.NewLogicalClock(0)))
+	engine.RegisterPrimitive("counter", &CountingPrimitive{version: "1.0.0"})
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	first := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	retry := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+
+	assert.Equal(t, first, retry)
+	assert.Equal(t, first.Proof.GeneratedAt, retry.Proof.GeneratedAt)
+}
+
+func TestIdempotentEvaluationRejectsConflictingContext(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 0))
+	engine.RegisterPrimitive("counter", &CountingPrimitive{version: "1.0.0"})
+
+	engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0), core.WithRequestID("req-1"))
+	conflict := engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 2}, 0), core.WithRequestID("req-1"))
+
+	assert.False(t, conflict.Permitted)
+	assert.Len(t, conflict.FailureReasons, 1)
+	assert.True(t, strings.HasPrefix(conflict.FailureReasons[0], string(core.FailureIdempotencyConflict)))
+}
+
+func TestIdempotencyWindowExpires(t *testing.T) {
+	clock := &ManualClock{}
+	engine := core.NewGovernanceEngine(core.WithIdempotencyClock(clock), core.WithIdempotencyWindow(10*time.Millisecond, 0))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+
+	engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0), core.WithRequestID("req-1"))
+	clock.Advance(9 * time.Millisecond)
+	conflict := engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 2}, 0), core.WithRequestID("req-1"))
+	assert.False(t, conflict.Permitted)
+
+	clock.Advance(time.Millisecond)
+	decision := engine.EvaluateContext(context.Background(), core.NewDeterministicContext(map[string]interface{}{"a": 2}, 0), core.WithRequestID("req-1"))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, int64(2), counter.calls.Load())
+}
+
+func TestIdempotencyWindowExpiresBehindPendingRequest(t *testing.T) {
+	clock := &ManualClock{}
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Second, 0), core.WithIdempotencyClock(clock))
+	blocking := NewBlockingPrimitive("1.0.0")
+	engine.RegisterPrimitive("slow", blocking, core.WithScope(core.Scope{ActionTypes: []string{"slow"}}))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter, core.WithScope(core.Scope{ActionTypes: []string{"fast"}}))
+	slow := core.NewDeterministicContext(map[string]interface{}{core.ActionTypeKey: "slow"}, 0)
+	fast := core.NewDeterministicContext(map[string]interface{}{core.ActionTypeKey: "fast"}, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		engine.EvaluateContext(context.Background(), slow, core.WithRequestID("req-a"))
+	}()
+	<-blocking.Started()
+
+	engine.EvaluateContext(context.Background(), fast, core.WithRequestID("req-b"))
+	clock.Advance(10 * time.Second)
+	engine.EvaluateContext(context.Background(), fast, core.WithRequestID("req-b"))
+	assert.Equal(t, int64(2), counter.calls.Load())
+
+	blocking.Release()
+	wg.Wait()
+}
+
+func TestIdempotencyEntryBound(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 1))
+	counter := &CountingPrimitive{version: "1.0.0"}
+	engine.RegisterPrimitive("counter", counter)
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-2"))
+	engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+
+	assert.Equal(t, int64(3), counter.calls.Load())
+}
+
+func TestIdempotencyDoesNotRecordTimeouts(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 0))
+	blocking := NewBlockingPrimitive("1.0.0")
+	engine.RegisterPrimitive("slow", blocking, core.WithTimeout(10*time.Millisecond))
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	first := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	assert.False(t, first.Permitted)
+
+	blocking.Release()
+	retry := engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	assert.True(t, retry.Permitted)
+}
+
+func TestConcurrentDuplicateRequestsEvaluateOnce(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithIdempotencyWindow(time.Minute, 0))
+	blocking := NewBlockingPrimitive("1.0.0")
+	engine.RegisterPrimitive("slow", blocking)
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	decisions := make([]*core.GovernanceDecision, 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		decisions[0] = engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	}()
+	<-blocking.Started()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		decisions[1] = engine.EvaluateContext(context.Background(), dctx, core.WithRequestID("req-1"))
+	}()
+	blocking.Release()
+	wg.Wait()
+
+	assert.Equal(t, decisions[0], decisions[1])
+}
@@ -0,0 +1,145 @@
+/*
+Unit tests for obligations and advice on governance decisions.
+*/
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// ConditionalPrimitive returns a fixed outcome with obligations and advice
+type ConditionalPrimitive struct {
+	name        string
+	valid       bool
+	obligations interface{}
+	advice      interface{}
+}
+
+func (c *ConditionalPrimitive) Name() string    { return c.name }
+func (c *ConditionalPrimitive) Version() string { return "1.0.0" }
+func (c *ConditionalPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"valid":       c.valid,
+		"metadata":    map[string]interface{}{},
+		"obligations": c.obligations,
+		"advice":      c.advice,
+	}
+}
+
+func TestDecisionAggregatesObligations(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("regulator", &ConditionalPrimitive{
+		name:        "regulator",
+		valid:       true,
+		obligations: []core.Obligation{{ID: "log_to_regulator_feed"}},
+		advice:      []string{"prefer_batching"},
+	})
+	engine.RegisterPrimitive("privacy", &ConditionalPrimitive{
+		name:  "privacy",
+		valid: true,
+		obligations: []interface{}{
+			map[string]interface{}{"id": "redact_field", "attributes": map[string]interface{}{"field": "ssn"}},
+		},
+	})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.Len(t, decision.Obligations, 2)
+	assert.Equal(t, "log_to_regulator_feed", decision.Obligations[0].ID)
+	assert.Equal(t, "regulator", decision.Obligations[0].Source)
+	assert.Equal(t, "ssn", decision.Obligations[1].Attributes["field"])
+	assert.Equal(t, "privacy", decision.Obligations[1].Source)
+	assert.Len(t, decision.Advice, 1)
+	assert.Equal(t, "prefer_batching", decision.Advice[0].ID)
+
+	assert.Len(t, decision.Proof.ObligationCommitments, 2)
+	assert.Len(t, decision.Proof.AdviceCommitments, 1)
+	pg := &core.ProofGenerator{}
+	assert.Equal(t, pg.CommitObligation(decision.Obligations[0]), decision.Proof.ObligationCommitments[0])
+}
+
+func TestEnforceObligations(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("regulator", &ConditionalPrimitive{
+		name:        "regulator",
+		valid:       true,
+		obligations: []string{"log_to_regulator_feed", "redact_field"},
+	})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	err := decision.EnforceObligations("redact_field")
+	assert.Error(t, err)
+	unfulfilled, ok := err.(*core.UnfulfilledObligationsError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"log_to_regulator_feed"}, unfulfilled.Missing)
+
+	assert.NoError(t, decision.EnforceObligations("redact_field", "log_to_regulator_feed"))
+}
+
+func TestEnforceObligationsOnDenial(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("denied", &ConditionalPrimitive{
+		name:        "denied",
+		valid:       false,
+		obligations: []string{"notify"},
+	})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.Empty(t, decision.Obligations)
+	assert.Error(t, decision.EnforceObligations("notify"))
+
+	var missing *core.GovernanceDecision
+	assert.Error(t, missing.EnforceObligations())
+}
+
+func TestMalformedObligationsFailClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("bad", &ConditionalPrimitive{
+		name:        "bad",
+		valid:       true,
+		obligations: []interface{}{map[string]interface{}{"attributes": map[string]interface{}{}}},
+	})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Indeterminate, decision.Outcome)
+	assert.Contains(t, decision.FailureReasons[0], "has no id")
+}
+
+func TestComposersAggregateObligations(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.SequentialAnd([]core.GovernancePrimitive{
+		&ConditionalPrimitive{name: "a", valid: true, obligations: []string{"log"}},
+		&ConditionalPrimitive{name: "b", valid: true, obligations: []string{"redact"}, advice: []string{"batch"}},
+	})
+
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("composite", composed)
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Len(t, decision.Obligations, 2)
+	assert.Equal(t, "a", decision.Obligations[0].Source)
+	assert.Equal(t, "b", decision.Obligations[1].Source)
+	assert.Len(t, decision.Advice, 1)
+
+	// Obligations of failing children are not attached
+	threshold := composer.Threshold([]core.GovernancePrimitive{
+		&ConditionalPrimitive{name: "a", valid: true, obligations: []string{"log"}},
+		&ConditionalPrimitive{name: "b", valid: false, obligations: []string{"redact"}},
+	}, 1)
+	result := threshold.Evaluate(nil)
+	obligations := result["obligations"].([]core.Obligation)
+	assert.Len(t, obligations, 1)
+	assert.Equal(t, "log", obligations[0].ID)
+}
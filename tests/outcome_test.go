@@ -0,0 +1,141 @@
+/*
+Unit tests for four-state primitive outcomes.
+*/
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func TestResultOutcome(t *testing.T) {
+	assert.Equal(t, core.Permit, core.ResultOutcome(map[string]interface{}{"valid": true}))
+	assert.Equal(t, core.Deny, core.ResultOutcome(map[string]interface{}{"valid": false}))
+	assert.Equal(t, core.Indeterminate, core.ResultOutcome(map[string]interface{}{}))
+	assert.Equal(t, core.Indeterminate, core.ResultOutcome(map[string]interface{}{"valid": "yes"}))
+	assert.Equal(t, core.NotApplicable, core.ResultOutcome(map[string]interface{}{"outcome": "not_applicable"}))
+	assert.Equal(t, core.Indeterminate, core.ResultOutcome(map[string]interface{}{"outcome": "maybe"}))
+	// Contradictory answers fail closed
+	assert.Equal(t, core.Indeterminate, core.ResultOutcome(map[string]interface{}{"outcome": core.Permit, "valid": false}))
+}
+
+func TestCombineOutcomes(t *testing.T) {
+	assert.Equal(t, core.NotApplicable, core.CombineOutcomes())
+	assert.Equal(t, core.NotApplicable, core.CombineOutcomes(core.NotApplicable, core.NotApplicable))
+	assert.Equal(t, core.Permit, core.CombineOutcomes(core.NotApplicable, core.Permit))
+	assert.Equal(t, core.Indeterminate, core.CombineOutcomes(core.Permit, core.Indeterminate, core.Permit))
+	assert.Equal(t, core.Deny, core.CombineOutcomes(core.Indeterminate, core.Deny))
+}
+
+func TestGovernanceEngineNotApplicableDoesNotBlock(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("payments", &OutcomePrimitive{name: "payments", version: "1.0.0", outcome: core.NotApplicable})
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, core.Permit, decision.Outcome)
+	assert.Equal(t, core.NotApplicable, decision.Signals[0]["outcome"])
+	assert.Equal(t, core.Permit, decision.Proof.Outcome)
+}
+
+func TestGovernanceEngineAllNotApplicableFailsClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("payments", &OutcomePrimitive{name: "payments", version: "1.0.0", outcome: core.NotApplicable})
+	engine.RegisterPrimitive("trades", &OutcomePrimitive{name: "trades", version: "1.0.0", outcome: core.NotApplicable})
+	engine.RegisterPrimitive("audit", &MockPrimitive{name: "audit", version: "1.0.0", valid: true}, core.AsShadow())
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Deny, decision.Outcome)
+	assert.Equal(t, []string{"No applicable primitive permitted the action"}, decision.FailureReasons)
+	assert.False(t, decision.Proof.Decision)
+}
+
+func TestGovernanceEngineIndeterminateFailsClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("unsure", &OutcomePrimitive{name: "unsure", version: "1.0.0", outcome: core.Indeterminate})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Indeterminate, decision.Outcome)
+	assert.Contains(t, decision.FailureReasons[0], "indeterminate")
+	assert.False(t, decision.Proof.Decision)
+	assert.Equal(t, core.Indeterminate, decision.Proof.Outcome)
+}
+
+func TestGovernanceEngineOutcomeChangesCommitment(t *testing.T) {
+	pg := &core.ProofGenerator{}
+	permit := pg.CommitSignal(map[string]interface{}{"valid": true, "outcome": core.Permit})
+	notApplicable := pg.CommitSignal(map[string]interface{}{"valid": true, "outcome": core.NotApplicable})
+	assert.NotEqual(t, permit, notApplicable)
+}
+
+func TestSequentialAndOutcomes(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.SequentialAnd([]core.GovernancePrimitive{
+		&OutcomePrimitive{name: "na", version: "1.0", outcome: core.NotApplicable},
+		&MockPrimitive{name: "pass", version: "1.0", valid: true},
+	})
+	assert.Equal(t, core.Permit, composed.Evaluate(nil)["outcome"])
+
+	composed = composer.SequentialAnd([]core.GovernancePrimitive{
+		&OutcomePrimitive{name: "unsure", version: "1.0", outcome: core.Indeterminate},
+		&MockPrimitive{name: "pass", version: "1.0", valid: true},
+	})
+	result := composed.Evaluate(nil)
+	assert.Equal(t, core.Indeterminate, result["outcome"])
+	assert.False(t, result["valid"].(bool))
+}
+
+func TestParallelAndAllNotApplicable(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	composed := composer.ParallelAnd([]core.GovernancePrimitive{
+		&OutcomePrimitive{name: "a", version: "1.0", outcome: core.NotApplicable},
+		&OutcomePrimitive{name: "b", version: "1.0", outcome: core.NotApplicable},
+	})
+	result := composed.Evaluate(nil)
+
+	assert.Equal(t, core.NotApplicable, result["outcome"])
+	assert.True(t, result["valid"].(bool))
+}
+
+func TestThresholdOutcomes(t *testing.T) {
+	composer := &core.PrimitiveComposer{}
+
+	// NotApplicable does not count towards k
+	composed := composer.Threshold([]core.GovernancePrimitive{
+		&MockPrimitive{name: "a", version: "1.0", valid: true},
+		&OutcomePrimitive{name: "b", version: "1.0", outcome: core.NotApplicable},
+	}, 2)
+	assert.Equal(t, core.Deny, composed.Evaluate(nil)["outcome"])
+
+	// Indeterminate fails closed when k is not met
+	composed = composer.Threshold([]core.GovernancePrimitive{
+		&MockPrimitive{name: "a", version: "1.0", valid: true},
+		&OutcomePrimitive{name: "b", version: "1.0", outcome: core.Indeterminate},
+	}, 2)
+	assert.Equal(t, core.Indeterminate, composed.Evaluate(nil)["outcome"])
+
+	// k met despite an Indeterminate child
+	composed = composer.Threshold([]core.GovernancePrimitive{
+		&MockPrimitive{name: "a", version: "1.0", valid: true},
+		&OutcomePrimitive{name: "b", version: "1.0", outcome: core.Indeterminate},
+	}, 1)
+	assert.Equal(t, core.Permit, composed.Evaluate(nil)["outcome"])
+}
@@ -0,0 +1,199 @@
+/*
+Unit tests for deterministic parallel evaluation.
+*/
+
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// HashingPrimitive does CPU-bound work and permits unless deny is set
+type HashingPrimitive struct {
+	seed int
+	deny bool
+}
+
+func (h *HashingPrimitive) Version() string { return fmt.Sprintf("hash-%d", h.seed) }
+func (h *HashingPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	x := uint64(h.seed)
+	for i := 0; i < 20000; i++ {
+		x = x*6364136223846793005 + 1442695040888963407
+	}
+	return map[string]interface{}{
+		"valid":    !h.deny,
+		"metadata": map[string]interface{}{"reason": fmt.Sprintf("digest %d", x%1000)},
+		"evidence": []interface{}{x % 1000},
+	}
+}
+
+// BarrierPrimitive permits only if n evaluations are in flight at once
+type BarrierPrimitive struct {
+	barrier *sync.WaitGroup
+}
+
+func (b *BarrierPrimitive) Version() string { return "1.0.0" }
+func (b *BarrierPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	b.barrier.Done()
+	done := make(chan struct{})
+	go func() {
+		b.barrier.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return map[string]interface{}{"valid": true}
+	case <-time.After(time.Second):
+		return map[string]interface{}{"valid": false}
+	}
+}
+
+// InFlightPrimitive records the peak number of its evaluations running at once
+type InFlightPrimitive struct {
+	inFlight, peak *atomic.Int64
+}
+
+func (p *InFlightPrimitive) Version() string { return "1.0.0" }
+func (p *InFlightPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for peak := p.peak.Load(); n > peak && !p.peak.CompareAndSwap(peak, n); peak = p.peak.Load() {
+	}
+	time.Sleep(time.Millisecond)
+	return map[string]interface{}{"valid": true}
+}
+
+// SlowPrimitive permits after a fixed delay, counting its evaluations
+type SlowPrimitive struct {
+	delay time.Duration
+	calls atomic.Int64
+}
+
+func (s *SlowPrimitive) Version() string { return "1.0.0" }
+func (s *SlowPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	return map[string]interface{}{"valid": true}
+}
+
+func registerHashing(engine *core.GovernanceEngine, denyAt ...int) {
+	for i := 0; i < 12; i++ {
+		deny := false
+		for _, d := range denyAt {
+			deny = deny || d == i
+		}
+		engine.RegisterPrimitive(fmt.Sprintf("p%02d", i), &HashingPrimitive{seed: i, deny: deny})
+	}
+	engine.RegisterPrimitive("dependent", &ResolverPrimitive{counterparty: "x", deps: []string{"p03"}})
+}
+
+func TestParallelEvaluationMatchesSerial(t *testing.T) {
+	for _, mode := range []core.EvaluationMode{core.FailFast, core.Exhaustive} {
+		serial := core.NewGovernanceEngine(core.WithDefaultMode(mode))
+		parallel := core.NewGovernanceEngine(core.WithDefaultMode(mode), core.WithParallelism(4))
+		registerHashing(serial, 5, 9)
+		registerHashing(parallel, 5, 9)
+
+		ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+		expected := serial.Evaluate(ctx, core.AtLogicalTime(7))
+		actual := parallel.Evaluate(ctx, core.AtLogicalTime(7))
+
+		assert.Equal(t, decisionBytes(t, expected), decisionBytes(t, actual), mode.String())
+		assert.False(t, actual.Permitted)
+	}
+}
+
+func TestParallelEvaluationPermit(t *testing.T) {
+	serial := core.NewGovernanceEngine(core.WithDefaultMode(core.Exhaustive))
+	parallel := core.NewGovernanceEngine(core.WithDefaultMode(core.Exhaustive), core.WithParallelism(8))
+	registerHashing(serial)
+	registerHashing(parallel)
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	actual := parallel.Evaluate(ctx, core.AtLogicalTime(1))
+
+	assert.True(t, actual.Permitted)
+	assert.Equal(t, decisionBytes(t, serial.Evaluate(ctx, core.AtLogicalTime(1))), decisionBytes(t, actual))
+}
+
+func TestParallelEvaluationRunsConcurrently(t *testing.T) {
+	var barrier sync.WaitGroup
+	barrier.Add(3)
+
+	engine := core.NewGovernanceEngine(core.WithParallelism(3), core.WithDefaultMode(core.Exhaustive))
+	for i := 0; i < 3; i++ {
+		engine.RegisterPrimitive(fmt.Sprintf("b%d", i), &BarrierPrimitive{barrier: &barrier})
+	}
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.True(t, decision.Permitted)
+}
+
+func TestParallelEvaluationStopsAfterFailFastDenial(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithParallelism(2))
+	engine.RegisterPrimitive("deny", &MockPrimitive{name: "deny", version: "1.0.0", valid: false})
+	later := &SlowPrimitive{delay: 2 * time.Millisecond}
+	for i := 0; i < 20; i++ {
+		engine.RegisterPrimitive(fmt.Sprintf("later%02d", i), later)
+	}
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"deny"}, decision.Proof.EvaluationOrder)
+	// Primitives queued behind the denial are not started
+	assert.Less(t, later.calls.Load(), int64(20))
+}
+
+func TestParallelEvaluationMatchesSerialUnderBudget(t *testing.T) {
+	for _, budget := range []uint64{3, 100} {
+		for _, mode := range []core.EvaluationMode{core.FailFast, core.Exhaustive} {
+			serial := core.NewGovernanceEngine(core.WithDefaultMode(mode), core.WithCostBudget(budget))
+			parallel := core.NewGovernanceEngine(core.WithDefaultMode(mode), core.WithCostBudget(budget), core.WithParallelism(4))
+			registerHashing(serial, 9)
+			registerHashing(parallel, 9)
+
+			ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+			expected := serial.Evaluate(ctx, core.AtLogicalTime(3))
+			actual := parallel.Evaluate(ctx, core.AtLogicalTime(3))
+
+			assert.Equal(t, decisionBytes(t, expected), decisionBytes(t, actual), "%s with budget %d", mode, budget)
+			assert.False(t, actual.Permitted)
+		}
+	}
+}
+
+func TestParallelAndRunsConcurrently(t *testing.T) {
+	var barrier sync.WaitGroup
+	barrier.Add(3)
+
+	composer := &core.PrimitiveComposer{Parallelism: 3}
+	composed := composer.ParallelAnd([]core.GovernancePrimitive{
+		&BarrierPrimitive{barrier: &barrier},
+		&BarrierPrimitive{barrier: &barrier},
+		&BarrierPrimitive{barrier: &barrier},
+	})
+
+	assert.True(t, composed.Evaluate(nil)["valid"].(bool))
+}
+
+func TestParallelAndBoundsConcurrency(t *testing.T) {
+	for _, parallelism := range []int{0, 2} {
+		var inFlight, peak atomic.Int64
+		children := make([]core.GovernancePrimitive, 6)
+		for i := range children {
+			children[i] = &InFlightPrimitive{inFlight: &inFlight, peak: &peak}
+		}
+		composer := &core.PrimitiveComposer{Parallelism: parallelism}
+
+		assert.True(t, composer.ParallelAnd(children).Evaluate(nil)["valid"].(bool))
+		assert.LessOrEqual(t, peak.Load(), int64(max(parallelism, 1)))
+	}
+}
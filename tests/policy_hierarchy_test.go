@@ -0,0 +1,151 @@
+/*
+Unit tests for hierarchical policy registries.
+*/
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// newTestHierarchy builds acme with an org, a team and an agent primitive
+func newTestHierarchy(t *testing.T) *core.PolicyHierarchy {
+	h, err := core.NewPolicyHierarchy("acme", core.WithClock(core.NewLogicalClock(0)))
+	assert.NoError(t, err)
+	assert.NoError(t, h.RegisterPrimitive("acme", "kyc", &MockPrimitive{name: "kyc", version: "1.0.0", valid: true}))
+	assert.NoError(t, h.RegisterPrimitive("acme/payments", "limit", &MockPrimitive{name: "limit", version: "1.0.0", valid: true}))
+	assert.NoError(t, h.RegisterPrimitive("acme/payments/agent-7", "hours", &MockPrimitive{name: "hours", version: "1.0.0", valid: true}))
+	return h
+}
+
+func TestHierarchyResolvesEffectivePrimitives(t *testing.T) {
+	h := newTestHierarchy(t)
+
+	ids, err := h.EffectivePrimitives("acme/payments/agent-7")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kyc", "limit", "hours"}, ids)
+
+	ids, err = h.EffectivePrimitives("acme/payments/agent-8")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kyc", "limit"}, ids)
+
+	ids, err = h.EffectivePrimitives("acme/lending")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kyc"}, ids)
+}
+
+func TestHierarchyProofRecordsLevels(t *testing.T) {
+	h := newTestHierarchy(t)
+
+	decision := h.Evaluate("acme/payments/agent-7", core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"kyc", "limit", "hours"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, map[string]core.PolicyLevel{
+		"kyc":   {Level: core.LevelOrg, Scope: "acme"},
+		"limit": {Level: core.LevelTeam, Scope: "acme/payments"},
+		"hours": {Level: core.LevelAgent, Scope: "acme/payments/agent-7"},
+	}, decision.Proof.PrimitiveLevels)
+}
+
+func TestHierarchyInheritedDenialCannotBeBypassed(t *testing.T) {
+	h := newTestHierarchy(t)
+	assert.NoError(t, h.RegisterPrimitive("acme", "sanctions", &MockPrimitive{name: "sanctions", version: "1.0.0", valid: false}))
+
+	decision := h.Evaluate("acme/payments/agent-7", core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.False(t, decision.Permitted)
+
+	// A child can neither remove nor replace the inherited primitive
+	assert.Error(t, h.UnregisterPrimitive("acme/payments/agent-7", "sanctions"))
+	assert.Error(t, h.RegisterPrimitive("acme/payments", "sanctions", &MockPrimitive{name: "sanctions", version: "2.0.0", valid: true}))
+	assert.Error(t, h.RegisterPrimitive("acme/payments/agent-9", "sanctions", &MockPrimitive{name: "sanctions", version: "2.0.0", valid: true}))
+}
+
+func TestHierarchyParentCannotShadowChildID(t *testing.T) {
+	h := newTestHierarchy(t)
+
+	assert.Error(t, h.RegisterPrimitive("acme", "hours", &MockPrimitive{name: "hours", version: "2.0.0", valid: true}))
+	// Siblings may reuse IDs
+	assert.NoError(t, h.RegisterPrimitive("acme/lending", "limit", &MockPrimitive{name: "limit", version: "3.0.0", valid: true}))
+}
+
+func TestHierarchyUnregisterOwnPrimitive(t *testing.T) {
+	h := newTestHierarchy(t)
+
+	assert.NoError(t, h.UnregisterPrimitive("acme/payments/agent-7", "hours"))
+	ids, err := h.EffectivePrimitives("acme/payments/agent-7")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kyc", "limit"}, ids)
+	assert.Error(t, h.UnregisterPrimitive("acme/payments/agent-7", "hours"))
+}
+
+func TestHierarchyChildMayDependOnParent(t *testing.T) {
+	h := newTestHierarchy(t)
+
+	assert.NoError(t, h.RegisterPrimitive("acme/payments", "fraud", &ResolverPrimitive{counterparty: "c-1", deps: []string{"kyc"}}))
+	assert.NoError(t, h.RegisterPrimitive("acme", "screening", &MockPrimitive{name: "screening", version: "1.0.0", valid: true}))
+	assert.NoError(t, h.RegisterPrimitive("acme/payments/agent-7", "velocity", &ResolverPrimitive{counterparty: "c-2", deps: []string{"screening"}}))
+	// Removing a primitive a descendant depends on would break the descendant
+	assert.Error(t, h.UnregisterPrimitive("acme", "screening"))
+
+	decision := h.Evaluate("acme/payments/agent-7", core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"kyc"}, decision.Proof.DependencyEdges["fraud"])
+}
+
+func TestHierarchyRejectsInvalidPaths(t *testing.T) {
+	h := newTestHierarchy(t)
+	mock := &MockPrimitive{name: "x", version: "1.0.0", valid: true}
+
+	assert.Error(t, h.RegisterPrimitive("other", "x", mock))
+	assert.Error(t, h.RegisterPrimitive("acme/a/b/c", "x", mock))
+	assert.Error(t, h.RegisterPrimitive("acme//b", "x", mock))
+
+	decision := h.Evaluate("other/team", core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.FailureReasons, 1)
+
+	_, err := core.NewPolicyHierarchy("")
+	assert.Error(t, err)
+}
+
+func TestHierarchyEpochAdvancesOnChange(t *testing.T) {
+	h := newTestHierarchy(t)
+	dctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+
+	before := h.Evaluate("acme/payments", dctx).Proof
+	assert.NoError(t, h.RegisterPrimitive("acme/lending", "rate", &MockPrimitive{name: "rate", version: "1.0.0", valid: true}))
+	after := h.Evaluate("acme/payments", dctx).Proof
+
+	assert.Greater(t, after.RegistryEpoch, before.RegistryEpoch)
+	assert.Equal(t, before.RegistryHash, after.RegistryHash)
+}
+
+func TestHierarchyRequestIDsDoNotCrossScopes(t *testing.T) {
+	h, err := core.NewPolicyHierarchy("acme", core.WithIdempotencyWindow(time.Minute, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, h.RegisterPrimitive("acme/pay", "ok", &MockPrimitive{name: "ok", version: "1.0.0", valid: true}))
+	assert.NoError(t, h.RegisterPrimitive("acme/ops", "deny", &MockPrimitive{name: "deny", version: "1.0.0", valid: false}))
+	dctx := core.NewDeterministicContext(map[string]interface{}{"a": 1}, 0)
+
+	pay := h.Evaluate("acme/pay", dctx, core.WithRequestID("r1"))
+	ops := h.Evaluate("acme/ops", dctx, core.WithRequestID("r1"))
+
+	assert.True(t, pay.Permitted)
+	assert.False(t, ops.Permitted)
+	assert.Equal(t, []string{"deny"}, ops.Proof.EvaluationOrder)
+
+	// Within one scope the request is still deduplicated
+	assert.Equal(t, pay, h.Evaluate("acme/pay", dctx, core.WithRequestID("r1")))
+
+	// Agents without primitives of their own share a configuration, not request IDs
+	first := h.Evaluate("acme/pay/agent-7", dctx, core.WithRequestID("r2"))
+	second := h.Evaluate("acme/pay/agent-8", core.NewDeterministicContext(map[string]interface{}{"a": 2}, 0), core.WithRequestID("r2"))
+	assert.True(t, first.Permitted)
+	assert.True(t, second.Permitted)
+	assert.Empty(t, second.FailureReasons)
+}
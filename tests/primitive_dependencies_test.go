@@ -0,0 +1,133 @@
+/*
+Unit tests for primitive dependencies and evidence passing.
+*/
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+// ResolverPrimitive resolves a fixed counterparty into its evidence
+type ResolverPrimitive struct {
+	counterparty string
+	deps         []string
+}
+
+func (r *ResolverPrimitive) Version() string        { return "1.0.0" }
+func (r *ResolverPrimitive) Dependencies() []string { return r.deps }
+func (r *ResolverPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"valid":    r.counterparty != "",
+		"evidence": map[string]interface{}{"counterparty": r.counterparty},
+	}
+}
+
+// SanctionsPrimitive denies counterparties resolved by entity_resolution that are sanctioned
+type SanctionsPrimitive struct {
+	sanctioned string
+}
+
+func (s *SanctionsPrimitive) Version() string        { return "1.0.0" }
+func (s *SanctionsPrimitive) Dependencies() []string { return []string{"entity_resolution"} }
+func (s *SanctionsPrimitive) Evaluate(ctx interface{}) map[string]interface{} {
+	upstream := ctx.(*core.DeterministicContext).Upstream()
+	evidence, ok := upstream.Evidence("entity_resolution")
+	if !ok {
+		return map[string]interface{}{"outcome": core.Indeterminate}
+	}
+	resolved := evidence.(map[string]interface{})
+	counterparty := resolved["counterparty"]
+	// Tampering with the copy must not reach the upstream signal
+	resolved["counterparty"] = "tampered"
+	return map[string]interface{}{
+		"valid":    counterparty != s.sanctioned,
+		"metadata": map[string]interface{}{"screened": counterparty},
+	}
+}
+
+func TestDependentPrimitiveReadsUpstreamEvidence(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	assert.NoError(t, engine.RegisterPrimitive("entity_resolution", &ResolverPrimitive{counterparty: "ACME Corp"}))
+	assert.NoError(t, engine.RegisterPrimitive("sanctions", &SanctionsPrimitive{sanctioned: "ACME Corp"}))
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"entity_resolution", "sanctions"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, "ACME Corp", decision.Signals[1]["metadata"].(map[string]interface{})["screened"])
+	assert.Equal(t, "ACME Corp", decision.Signals[0]["evidence"].(map[string]interface{})["counterparty"])
+	assert.Equal(t, map[string][]string{"sanctions": {"entity_resolution"}}, decision.Proof.DependencyEdges)
+}
+
+func TestDependencyMustBeRegistered(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	err := engine.RegisterPrimitive("sanctions", &SanctionsPrimitive{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unregistered primitive 'entity_resolution'")
+	assert.Equal(t, 0, engine.PrimitiveCount())
+
+	err = engine.RegisterPrimitive("self", &ResolverPrimitive{counterparty: "x", deps: []string{"self"}})
+	assert.Error(t, err)
+}
+
+func TestReplaceReordersAndRejectsCycles(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("x", &ResolverPrimitive{counterparty: "x"})
+	engine.RegisterPrimitive("y", &ResolverPrimitive{counterparty: "y"})
+
+	// x now depends on y, so y must run first
+	assert.NoError(t, engine.ReplacePrimitive("x", &ResolverPrimitive{counterparty: "x", deps: []string{"y"}}))
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.Equal(t, []string{"y", "x"}, decision.Proof.EvaluationOrder)
+
+	epoch := engine.RegistryEpoch()
+	err := engine.ReplacePrimitive("y", &ResolverPrimitive{counterparty: "y", deps: []string{"x"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+	assert.Equal(t, epoch, engine.RegistryEpoch())
+}
+
+func TestUnregisterDependencyRejected(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("entity_resolution", &ResolverPrimitive{counterparty: "ACME Corp"})
+	engine.RegisterPrimitive("sanctions", &SanctionsPrimitive{})
+
+	assert.Error(t, engine.UnregisterPrimitive("entity_resolution"))
+	assert.NoError(t, engine.UnregisterPrimitive("sanctions"))
+	assert.NoError(t, engine.UnregisterPrimitive("entity_resolution"))
+}
+
+func TestFailedDependencyFailsDownstreamClosed(t *testing.T) {
+	engine := core.NewGovernanceEngine(core.WithDefaultMode(core.Exhaustive))
+	engine.RegisterPrimitive("entity_resolution", &ResolverPrimitive{counterparty: ""})
+	engine.RegisterPrimitive("sanctions", &SanctionsPrimitive{sanctioned: "ACME Corp"})
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+
+	assert.False(t, decision.Permitted)
+	assert.Len(t, decision.FailureReasons, 2)
+	assert.Contains(t, decision.FailureReasons[1], "dependency 'entity_resolution' did not permit")
+	assert.Equal(t, core.Indeterminate, decision.Signals[1]["outcome"])
+}
+
+func TestGatingPrimitiveCannotDependOnShadow(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	assert.NoError(t, engine.RegisterPrimitive("shadow", &ResolverPrimitive{counterparty: ""}, core.AsShadow()))
+
+	err := engine.RegisterPrimitive("gate", &ResolverPrimitive{counterparty: "x", deps: []string{"shadow"}})
+	assert.EqualError(t, err, "gating primitive 'gate' cannot depend on shadow primitive 'shadow'")
+	assert.Equal(t, 1, engine.PrimitiveCount())
+
+	// A shadow primitive may depend on another, and demoting a dependency is rejected
+	assert.NoError(t, engine.RegisterPrimitive("observer", &ResolverPrimitive{counterparty: "x", deps: []string{"shadow"}}, core.AsShadow()))
+	assert.NoError(t, engine.RegisterPrimitive("base", &ResolverPrimitive{counterparty: "x"}))
+	assert.NoError(t, engine.RegisterPrimitive("dependent", &ResolverPrimitive{counterparty: "x", deps: []string{"base"}}))
+	assert.Error(t, engine.ReplacePrimitive("base", &ResolverPrimitive{counterparty: "x"}, core.AsShadow()))
+
+	decision := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	assert.True(t, decision.Permitted)
+}
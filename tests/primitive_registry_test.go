@@ -0,0 +1,115 @@
+/*
+Unit tests for the copy-on-write primitive registry.
+*/
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func TestRegistryUnregisterPrimitive(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("denied", &MockPrimitive{name: "denied", version: "1.0.0", valid: false})
+
+	assert.NoError(t, engine.UnregisterPrimitive("denied"))
+	assert.Equal(t, 1, engine.PrimitiveCount())
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+	assert.True(t, decision.Permitted)
+	assert.NotContains(t, decision.Proof.PrimitiveVersions, "denied")
+
+	err := engine.UnregisterPrimitive("denied")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not registered")
+}
+
+func TestRegistryReplacePrimitiveKeepsPosition(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("a", &MockPrimitive{name: "a", version: "1.0.0", valid: false})
+	engine.RegisterPrimitive("b", &MockPrimitive{name: "b", version: "1.0.0", valid: true})
+
+	assert.NoError(t, engine.ReplacePrimitive("a", &MockPrimitive{name: "a", version: "2.0.0", valid: true}))
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"a", "b"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, "2.0.0", decision.Proof.PrimitiveVersions["a"])
+
+	assert.Error(t, engine.ReplacePrimitive("missing", &MockPrimitive{name: "m", version: "1.0.0", valid: true}))
+	assert.Error(t, engine.ReplacePrimitive("a", nil))
+}
+
+func TestRegistryEpochAndHash(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	emptyHash := engine.RegistryHash()
+	assert.Equal(t, uint64(0), engine.RegistryEpoch())
+
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	assert.Equal(t, uint64(1), engine.RegistryEpoch())
+	authHash := engine.RegistryHash()
+	assert.NotEqual(t, emptyHash, authHash)
+
+	engine.ReplacePrimitive("auth", &MockPrimitive{name: "auth", version: "1.1.0", valid: true})
+	assert.Equal(t, uint64(2), engine.RegistryEpoch())
+	assert.NotEqual(t, authHash, engine.RegistryHash())
+
+	engine.Clear()
+	assert.Equal(t, uint64(3), engine.RegistryEpoch())
+	assert.Equal(t, emptyHash, engine.RegistryHash())
+
+	// Failed changes do not advance the epoch
+	engine.UnregisterPrimitive("auth")
+	assert.Equal(t, uint64(3), engine.RegistryEpoch())
+}
+
+func TestRegistryEpochRecordedInProof(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.Equal(t, engine.RegistryEpoch(), decision.Proof.RegistryEpoch)
+	assert.Equal(t, engine.RegistryHash(), decision.Proof.RegistryHash)
+}
+
+func TestRegistryChangesDoNotWaitForEvaluation(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	slow := NewBlockingPrimitive("1.0.0")
+	engine.RegisterPrimitive("slow", slow)
+	epoch := engine.RegistryEpoch()
+
+	done := make(chan *core.GovernanceDecision)
+	go func() {
+		done <- engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{}, 0))
+	}()
+	<-slow.Started()
+
+	registered := make(chan error)
+	go func() {
+		registered <- engine.RegisterPrimitive("late", &MockPrimitive{name: "late", version: "1.0.0", valid: false})
+	}()
+
+	select {
+	case err := <-registered:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("registration blocked behind evaluation")
+	}
+
+	slow.Release()
+	decision := <-done
+
+	// The in-flight evaluation used the snapshot taken when it started
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"slow"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, epoch, decision.Proof.RegistryEpoch)
+}
@@ -0,0 +1,104 @@
+/*
+Unit tests for action-scoped primitive routing.
+*/
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gsas/core"
+)
+
+func newScopedEngine() *core.GovernanceEngine {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("auth", &MockPrimitive{name: "auth", version: "1.0.0", valid: true})
+	engine.RegisterPrimitive("max_transfer", &MockPrimitive{name: "max_transfer", version: "1.0.0", valid: false},
+		core.WithScope(core.Scope{ActionTypes: []string{"payment"}}))
+	return engine
+}
+
+func TestScopeSkipsOutOfScopePrimitives(t *testing.T) {
+	engine := newScopedEngine()
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{"action_type": "file_write"}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"auth"}, decision.Proof.EvaluationOrder)
+	assert.Len(t, decision.Proof.SkippedPrimitives, 1)
+	assert.Equal(t, "max_transfer", decision.Proof.SkippedPrimitives[0].PrimitiveID)
+	assert.Contains(t, decision.Proof.SkippedPrimitives[0].Reason, "file_write")
+}
+
+func TestScopeEvaluatesInScopePrimitives(t *testing.T) {
+	engine := newScopedEngine()
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{"action_type": "payment"}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, []string{"auth", "max_transfer"}, decision.Proof.EvaluationOrder)
+	assert.Empty(t, decision.Proof.SkippedPrimitives)
+}
+
+func TestScopeTagsAndPredicate(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("pii", &MockPrimitive{name: "pii", version: "1.0.0", valid: true},
+		core.WithScope(core.Scope{Tags: []string{"pii", "gdpr"}}))
+	engine.RegisterPrimitive("large", &MockPrimitive{name: "large", version: "1.0.0", valid: true},
+		core.WithScope(core.Scope{PredicateID: "has_amount/v1", Predicate: func(dc *core.DeterministicContext) bool {
+			return dc.Has("amount")
+		}}))
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{"tags": []string{"gdpr"}}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.True(t, decision.Permitted)
+	assert.Equal(t, []string{"pii"}, decision.Proof.EvaluationOrder)
+	assert.Equal(t, "large", decision.Proof.SkippedPrimitives[0].PrimitiveID)
+	assert.Contains(t, decision.Proof.SkippedPrimitives[0].Reason, "predicate 'has_amount/v1'")
+}
+
+func TestScopePredicateIsIdentifiedInRegistryHash(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	hasAmount := func(dc *core.DeterministicContext) bool { return dc.Has("amount") }
+	primitive := &MockPrimitive{name: "large", version: "1.0.0", valid: true}
+
+	err := engine.RegisterPrimitive("large", primitive, core.WithScope(core.Scope{Predicate: hasAmount}))
+	assert.EqualError(t, err, "primitive 'large' has an invalid scope: scope predicate requires a PredicateID")
+	assert.Error(t, engine.RegisterPrimitive("large", primitive, core.WithScope(core.Scope{PredicateID: "orphan/v1"})))
+
+	assert.NoError(t, engine.RegisterPrimitive("large", primitive, core.WithScope(core.Scope{PredicateID: "large/v1", Predicate: hasAmount})))
+	hash := engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"amount": 1}, 0)).Proof.RegistryHash
+
+	assert.NoError(t, engine.ReplacePrimitive("large", primitive, core.WithScope(core.Scope{PredicateID: "large/v2", Predicate: hasAmount})))
+	assert.NotEqual(t, hash, engine.Evaluate(core.NewDeterministicContext(map[string]interface{}{"amount": 1}, 0)).Proof.RegistryHash)
+}
+
+func TestScopeFailsClosedWithoutApplicablePrimitives(t *testing.T) {
+	engine := core.NewGovernanceEngine()
+	engine.RegisterPrimitive("max_transfer", &MockPrimitive{name: "max_transfer", version: "1.0.0", valid: true},
+		core.WithScope(core.Scope{ActionTypes: []string{"payment"}}))
+	engine.RegisterPrimitive("shadow", &MockPrimitive{name: "shadow", version: "1.0.0", valid: true}, core.AsShadow())
+
+	ctx := core.NewDeterministicContext(map[string]interface{}{"action_type": "deploy"}, 0)
+	decision := engine.Evaluate(ctx)
+
+	assert.False(t, decision.Permitted)
+	assert.Equal(t, core.Deny, decision.Outcome)
+	assert.Contains(t, decision.FailureReasons[0], "No primitives registered for action type 'deploy'")
+	assert.False(t, decision.Proof.Decision)
+	assert.Equal(t, "max_transfer", decision.Proof.SkippedPrimitives[0].PrimitiveID)
+}
+
+func TestScopeChangesRegistryHash(t *testing.T) {
+	a := core.NewGovernanceEngine()
+	a.RegisterPrimitive("p", &MockPrimitive{name: "p", version: "1.0.0", valid: true})
+	b := core.NewGovernanceEngine()
+	b.RegisterPrimitive("p", &MockPrimitive{name: "p", version: "1.0.0", valid: true},
+		core.WithScope(core.Scope{ActionTypes: []string{"payment"}}))
+
+	assert.NotEqual(t, a.RegistryHash(), b.RegistryHash())
+}